@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ironcore-dev/controller-utils/unstructuredutils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ApplySubresource makes ApplyMultiple apply the given subresource instead of the main resource.
+type ApplySubresource string
+
+// ApplyStatus makes ApplyMultiple apply the status subresource.
+const ApplyStatus ApplySubresource = "status"
+
+// ApplyToPatch implements client.PatchOption. It is a no-op, ApplySubresource is only respected by ApplyMultiple.
+func (ApplySubresource) ApplyToPatch(*client.PatchOptions) {}
+
+// FieldConflict is a field whose ownership conflicted with another field manager when applying an object.
+type FieldConflict struct {
+	// Field is the path of the conflicting field, e.g. '.spec.replicas'.
+	Field string
+	// Message is the message reported by the server, usually naming the conflicting field manager.
+	Message string
+}
+
+// FieldConflicts extracts the field conflicts reported by the server from an apply error.
+// If the error is no conflict or does not report any field, nil is returned.
+func FieldConflicts(err error) []FieldConflict {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || !apierrors.IsConflict(err) {
+		return nil
+	}
+
+	details := status.Status().Details
+	if details == nil {
+		return nil
+	}
+
+	var conflicts []FieldConflict
+	for _, cause := range details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflicts = append(conflicts, FieldConflict{
+			Field:   cause.Field,
+			Message: cause.Message,
+		})
+	}
+	return conflicts
+}
+
+// ApplyConflictError is returned by ApplyMultiple if applying an object failed due to field ownership conflicts.
+// Use client.ForceOwnership to take over the ownership of conflicting fields.
+type ApplyConflictError struct {
+	// Conflicts are the conflicting fields.
+	Conflicts []FieldConflict
+	// Err is the original error returned by the server.
+	Err error
+}
+
+// Error implements error.
+func (e *ApplyConflictError) Error() string {
+	fields := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		fields = append(fields, conflict.Field)
+	}
+	return fmt.Sprintf("conflicting fields %s: %v", strings.Join(fields, ", "), e.Err)
+}
+
+// Unwrap returns the original error returned by the server.
+func (e *ApplyConflictError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyMultipleFromFile reads the given file as unstructured objects and applies them using ApplyMultiple.
+// The returned unstructured.Unstructured objects contain the result of applying them.
+func ApplyMultipleFromFile(
+	ctx context.Context,
+	c client.Client,
+	filename string,
+	fieldManager string,
+	opts ...client.PatchOption,
+) ([]unstructured.Unstructured, error) {
+	objs, err := unstructuredutils.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ApplyMultiple(ctx, c, unstructuredutils.UnstructuredSliceToObjectSliceNoCopy(objs), fieldManager, opts...); err != nil {
+		return nil, err
+	}
+	return objs, nil
+}
+
+// ApplyMultiple applies the given objects server-side using the given field manager.
+// The objects are updated in-place with the result of applying them.
+//
+// Any client.PatchOption, e.g. client.ForceOwnership or client.DryRunAll, is passed on to the client. To apply
+// the status instead of the main resource, pass ApplyStatus. Any MultipleOption (e.g. OrderByKind, MaxConcurrency
+// or ContinueOnError) controls how the objects are processed.
+// If applying an object fails due to field ownership conflicts, the error of that object is an *ApplyConflictError.
+func ApplyMultiple(ctx context.Context, c client.Client, objs []client.Object, fieldManager string, opts ...client.PatchOption) error {
+	o, opts := splitMultipleOptions(opts)
+
+	var (
+		subresource ApplySubresource
+		patchOpts   = &client.PatchOptions{}
+	)
+	for _, opt := range opts {
+		if s, ok := opt.(ApplySubresource); ok {
+			subresource = s
+			continue
+		}
+		opt.ApplyToPatch(patchOpts)
+	}
+	patchOpts.FieldManager = fieldManager
+
+	return o.run(ctx, c, batch{
+		verb: VerbApply,
+		objs: objs,
+		do: func(ctx context.Context, i int) error {
+			return applyObject(ctx, c, objs[i], subresource, patchOpts)
+		},
+		wrap: func(i int, err error) error {
+			return fmt.Errorf("error applying object %s: %w",
+				client.ObjectKeyFromObject(objs[i]),
+				err,
+			)
+		},
+	})
+}
+
+func applyObject(ctx context.Context, c client.Client, obj client.Object, subresource ApplySubresource, patchOpts *client.PatchOptions) error {
+	// Typed objects usually come without type information, which is required for an apply patch.
+	if obj.GetObjectKind().GroupVersionKind().Empty() {
+		gvk, err := c.GroupVersionKindFor(obj)
+		if err != nil {
+			return fmt.Errorf("error getting group version kind: %w", err)
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	// Managed fields must not be present when applying.
+	obj.SetManagedFields(nil)
+
+	var err error
+	if subresource == "" {
+		err = c.Patch(ctx, obj, applyPatch{}, patchOpts)
+	} else {
+		err = c.SubResource(string(subresource)).Patch(ctx, obj, applyPatch{}, &client.SubResourcePatchOptions{PatchOptions: *patchOpts})
+	}
+	if conflicts := FieldConflicts(err); len(conflicts) > 0 {
+		return &ApplyConflictError{Conflicts: conflicts, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	"github.com/ironcore-dev/controller-utils/testdata"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Apply", func() {
+	const (
+		objectsPath  = "../testdata/bases/objects.yaml"
+		fieldManager = "my-manager"
+	)
+
+	type patchCall struct {
+		Subresource string
+		Key         client.ObjectKey
+		Kind        string
+		PatchType   types.PatchType
+		Options     client.PatchOptions
+	}
+
+	var (
+		ctx     context.Context
+		c       client.Client
+		calls   []patchCall
+		patchFn func(obj client.Object) error
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		calls = nil
+		patchFn = func(client.Object) error { return nil }
+
+		record := func(subresource string, obj client.Object, patch client.Patch, opts *client.PatchOptions) error {
+			calls = append(calls, patchCall{
+				Subresource: subresource,
+				Key:         client.ObjectKeyFromObject(obj),
+				Kind:        obj.GetObjectKind().GroupVersionKind().Kind,
+				PatchType:   patch.Type(),
+				Options:     *opts,
+			})
+			return patchFn(obj)
+		}
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, _ client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					return record("", obj, patch, (&client.PatchOptions{}).ApplyOptions(opts))
+				},
+				SubResourcePatch: func(ctx context.Context, _ client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+					o := &client.SubResourcePatchOptions{}
+					o.ApplyOptions(opts)
+					return record(subResourceName, obj, patch, &o.PatchOptions)
+				},
+			}).
+			Build()
+	})
+
+	Describe("ApplyMultiple", func() {
+		It("should apply the objects with the field manager and pass through the options", func() {
+			cm := testdata.ConfigMap()
+			cm.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "other"}}
+			secret := testdata.Secret()
+
+			Expect(ApplyMultiple(ctx, c, []client.Object{cm, secret}, fieldManager, client.ForceOwnership, client.DryRunAll)).To(Succeed())
+
+			Expect(calls).To(HaveLen(2))
+			Expect(calls[0]).To(HaveField("Subresource", ""))
+			Expect(calls[0]).To(HaveField("Key", client.ObjectKeyFromObject(cm)))
+			Expect(calls[0]).To(HaveField("Kind", "ConfigMap"))
+			Expect(calls[0]).To(HaveField("PatchType", types.ApplyPatchType))
+			Expect(calls[0].Options.FieldManager).To(Equal(fieldManager))
+			Expect(calls[0].Options.Force).To(HaveValue(BeTrue()))
+			Expect(calls[0].Options.DryRun).To(Equal([]string{metav1.DryRunAll}))
+			Expect(calls[1]).To(HaveField("Kind", "Secret"))
+			Expect(cm.ManagedFields).To(BeNil())
+		})
+
+		It("should apply the status subresource", func() {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}
+
+			Expect(ApplyMultiple(ctx, c, []client.Object{pod}, fieldManager, ApplyStatus)).To(Succeed())
+
+			Expect(calls).To(ConsistOf(HaveField("Subresource", "status")))
+			Expect(calls[0].Options.FieldManager).To(Equal(fieldManager))
+			Expect(calls[0].Options.Force).To(BeNil())
+		})
+
+		It("should report conflicting fields", func() {
+			patchFn = func(obj client.Object) error {
+				if obj.GetName() != testdata.Secret().Name {
+					return nil
+				}
+				return apierrors.NewApplyConflict([]metav1.StatusCause{
+					{
+						Type:    metav1.CauseTypeFieldManagerConflict,
+						Field:   ".stringData.foo",
+						Message: `conflict with "other-manager"`,
+					},
+				}, "Apply failed with 1 conflict")
+			}
+
+			err := ApplyMultiple(ctx, c, []client.Object{testdata.ConfigMap(), testdata.Secret()}, fieldManager)
+			Expect(apierrors.IsConflict(err)).To(BeTrue())
+
+			var conflictErr *ApplyConflictError
+			Expect(errors.As(err, &conflictErr)).To(BeTrue())
+			Expect(conflictErr.Conflicts).To(Equal([]FieldConflict{
+				{Field: ".stringData.foo", Message: `conflict with "other-manager"`},
+			}))
+			Expect(err).To(MatchError(ContainSubstring(".stringData.foo")))
+		})
+
+		It("should not report conflicts for other errors", func() {
+			Expect(FieldConflicts(apierrors.NewConflict(corev1.Resource("configmaps"), "my-cm", errors.New("stale")))).To(BeEmpty())
+			Expect(FieldConflicts(errors.New("some error"))).To(BeEmpty())
+		})
+	})
+
+	Describe("ApplyMultipleFromFile", func() {
+		It("should error if the file does not exist", func() {
+			_, err := ApplyMultipleFromFile(ctx, c, "should-not-exist", fieldManager)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should apply multiple objects from file", func() {
+			objs, err := ApplyMultipleFromFile(ctx, c, objectsPath, fieldManager, ContinueOnError{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(2))
+			Expect(calls).To(HaveLen(2))
+			Expect(calls[0]).To(HaveField("Kind", "Secret"))
+			Expect(calls[1]).To(HaveField("Kind", "ConfigMap"))
+		})
+	})
+})
@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ironcore-dev/controller-utils/metautils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ApplySetParentIDLabel is the label on the parent object of an ApplySet containing the ApplySet id.
+	ApplySetParentIDLabel = "applyset.kubernetes.io/id"
+	// ApplySetPartOfLabel is the label on every member of an ApplySet containing the ApplySet id.
+	ApplySetPartOfLabel = "applyset.kubernetes.io/part-of"
+	// ApplySetGroupKindsAnnotation is the annotation on the parent object of an ApplySet containing the
+	// comma-separated, sorted list of group kinds the ApplySet may have members of.
+	ApplySetGroupKindsAnnotation = "applyset.kubernetes.io/contains-group-kinds"
+	// ApplySetToolingAnnotation is the annotation on the parent object of an ApplySet naming the managing tool.
+	ApplySetToolingAnnotation = "applyset.kubernetes.io/tooling"
+)
+
+// ApplySetID returns the id of the ApplySet with the given parent.
+func ApplySetID(parent ObjectRef) string {
+	unencoded := strings.Join([]string{parent.Key.Name, parent.Key.Namespace, parent.GroupKind.Kind, parent.GroupKind.Group}, ".")
+	hashed := sha256.Sum256([]byte(unencoded))
+	return fmt.Sprintf("applyset-%s-v1", base64.RawURLEncoding.EncodeToString(hashed[:]))
+}
+
+// ApplySet is a set of objects that are applied together.
+//
+// The ApplySet is identified by a parent object that has to exist. Every applied object is labeled with
+// ApplySetPartOfLabel, and the parent remembers the group kinds of the members. Members that are no longer part
+// of the applied objects are pruned, so objects removed from a manifest bundle are removed from the cluster as well.
+type ApplySet struct {
+	c            client.Client
+	parent       client.Object
+	parentRef    ObjectRef
+	id           string
+	fieldManager string
+}
+
+// NewApplySet creates a new ApplySet with the given parent object that applies objects using the given field manager.
+func NewApplySet(c client.Client, parent client.Object, fieldManager string) (*ApplySet, error) {
+	gvk, err := c.GroupVersionKindFor(parent)
+	if err != nil {
+		return nil, fmt.Errorf("error getting parent group version kind: %w", err)
+	}
+
+	parentRef := ObjectRef{GroupKind: gvk.GroupKind(), Key: client.ObjectKeyFromObject(parent)}
+	return &ApplySet{
+		c:            c,
+		parent:       parent,
+		parentRef:    parentRef,
+		id:           ApplySetID(parentRef),
+		fieldManager: fieldManager,
+	}, nil
+}
+
+// ID returns the id of the ApplySet.
+func (s *ApplySet) ID() string {
+	return s.id
+}
+
+// ApplySetResult is the result of applying an ApplySet.
+type ApplySetResult struct {
+	// Applied references all applied objects.
+	Applied ObjectRefSet
+	// Pruned references all pruned objects. On a dry run, these are the objects that would have been pruned.
+	Pruned []ObjectRef
+}
+
+// Apply applies the given objects as members of the ApplySet and prunes all members that are not part of objs.
+// The objects are updated in-place with the result of applying them.
+//
+// The options are passed to ApplyMultiple. If client.DryRunAll is passed, neither the parent is updated nor any
+// object is pruned; the returned ApplySetResult reports the objects that would have been pruned.
+// Nothing is pruned if applying any object failed.
+func (s *ApplySet) Apply(ctx context.Context, objs []client.Object, opts ...client.PatchOption) (*ApplySetResult, error) {
+	applied, groupKinds, err := s.refsFor(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	previousGroupKinds, err := s.getParentGroupKinds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dryRun := len((&client.PatchOptions{}).ApplyOptions(opts).DryRun) > 0
+	if !dryRun {
+		// Record the union before applying so members of new kinds are pruned even if we fail midway.
+		if err := s.patchParent(ctx, unionGroupKinds(previousGroupKinds, groupKinds)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, obj := range objs {
+		metautils.SetLabel(obj, ApplySetPartOfLabel, s.id)
+	}
+	if err := ApplyMultiple(ctx, s.c, objs, s.fieldManager, opts...); err != nil {
+		return nil, err
+	}
+
+	pruned, err := s.prunable(ctx, unionGroupKinds(previousGroupKinds, groupKinds), applied)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ApplySetResult{Applied: applied, Pruned: pruned}
+	if dryRun {
+		return res, nil
+	}
+
+	prunedObjs, err := s.objectsFor(pruned)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DeleteMultipleIfExist(ctx, s.c, prunedObjs); err != nil {
+		return nil, fmt.Errorf("error pruning objects: %w", err)
+	}
+	if err := s.patchParent(ctx, groupKinds); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Prunable lists the members of the ApplySet that would be pruned when applying the given objects.
+// Neither the objects nor the parent are modified.
+func (s *ApplySet) Prunable(ctx context.Context, objs []client.Object) ([]ObjectRef, error) {
+	applied, groupKinds, err := s.refsFor(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	previousGroupKinds, err := s.getParentGroupKinds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.prunable(ctx, unionGroupKinds(previousGroupKinds, groupKinds), applied)
+}
+
+func (s *ApplySet) refsFor(objs []client.Object) (ObjectRefSet, []schema.GroupKind, error) {
+	refs := NewObjectRefSet()
+	var groupKinds []schema.GroupKind
+	for i, obj := range objs {
+		gvk, err := s.c.GroupVersionKindFor(obj)
+		if err != nil {
+			return nil, nil, fmt.Errorf("[object %d]: error getting group version kind: %w", i, err)
+		}
+
+		refs.Insert(ObjectRef{GroupKind: gvk.GroupKind(), Key: client.ObjectKeyFromObject(obj)})
+		groupKinds = unionGroupKinds(groupKinds, []schema.GroupKind{gvk.GroupKind()})
+	}
+	return refs, groupKinds, nil
+}
+
+func (s *ApplySet) getParentGroupKinds(ctx context.Context) ([]schema.GroupKind, error) {
+	if err := s.c.Get(ctx, s.parentRef.Key, s.parent); err != nil {
+		return nil, fmt.Errorf("error getting apply set parent %s: %w", s.parentRef.Key, err)
+	}
+
+	if id, ok := s.parent.GetLabels()[ApplySetParentIDLabel]; ok && id != s.id {
+		return nil, fmt.Errorf("apply set parent %s has unexpected id %q, expected %q", s.parentRef.Key, id, s.id)
+	}
+
+	value := s.parent.GetAnnotations()[ApplySetGroupKindsAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	var groupKinds []schema.GroupKind
+	for _, item := range strings.Split(value, ",") {
+		groupKinds = append(groupKinds, schema.ParseGroupKind(item))
+	}
+	return groupKinds, nil
+}
+
+func (s *ApplySet) patchParent(ctx context.Context, groupKinds []schema.GroupKind) error {
+	value := formatGroupKinds(groupKinds)
+	if s.parent.GetLabels()[ApplySetParentIDLabel] == s.id &&
+		s.parent.GetAnnotations()[ApplySetGroupKindsAnnotation] == value &&
+		s.parent.GetAnnotations()[ApplySetToolingAnnotation] == s.fieldManager {
+		return nil
+	}
+
+	base := s.parent.DeepCopyObject().(client.Object)
+	metautils.SetLabel(s.parent, ApplySetParentIDLabel, s.id)
+	metautils.SetAnnotations(s.parent, map[string]string{
+		ApplySetGroupKindsAnnotation: value,
+		ApplySetToolingAnnotation:    s.fieldManager,
+	})
+	if err := s.c.Patch(ctx, s.parent, client.MergeFrom(base)); err != nil {
+		return fmt.Errorf("error patching apply set parent %s: %w", s.parentRef.Key, err)
+	}
+	return nil
+}
+
+// prunable lists the members of the given group kinds that are not in keep.
+// Group kinds that are no longer served are skipped.
+func (s *ApplySet) prunable(ctx context.Context, groupKinds []schema.GroupKind, keep ObjectRefSet) ([]ObjectRef, error) {
+	var res []ObjectRef
+	for _, gk := range groupKinds {
+		mapping, err := s.c.RESTMapper().RESTMapping(gk)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error getting rest mapping for %s: %w", gk, err)
+		}
+
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(mapping.GroupVersionKind.GroupVersion().WithKind(mapping.GroupVersionKind.Kind + "List"))
+		if err := s.c.List(ctx, list, client.MatchingLabels{ApplySetPartOfLabel: s.id}); err != nil {
+			return nil, fmt.Errorf("error listing members of kind %s: %w", gk, err)
+		}
+
+		for _, item := range list.Items {
+			ref := ObjectRef{GroupKind: gk, Key: client.ObjectKeyFromObject(&item)}
+			if !keep.Has(ref) {
+				res = append(res, ref)
+			}
+		}
+	}
+	slices.SortFunc(res, CompareObjectRefs)
+	return res, nil
+}
+
+func (s *ApplySet) objectsFor(refs []ObjectRef) ([]client.Object, error) {
+	objs := make([]client.Object, 0, len(refs))
+	for _, ref := range refs {
+		mapping, err := s.c.RESTMapper().RESTMapping(ref.GroupKind)
+		if err != nil {
+			return nil, fmt.Errorf("error getting rest mapping for %s: %w", ref.GroupKind, err)
+		}
+
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(mapping.GroupVersionKind)
+		obj.SetNamespace(ref.Key.Namespace)
+		obj.SetName(ref.Key.Name)
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func unionGroupKinds(gks1, gks2 []schema.GroupKind) []schema.GroupKind {
+	seen := make(map[schema.GroupKind]struct{}, len(gks1)+len(gks2))
+	var res []schema.GroupKind
+	for _, gks := range [][]schema.GroupKind{gks1, gks2} {
+		for _, gk := range gks {
+			if _, ok := seen[gk]; ok {
+				continue
+			}
+			seen[gk] = struct{}{}
+			res = append(res, gk)
+		}
+	}
+	return res
+}
+
+func formatGroupKinds(gks []schema.GroupKind) string {
+	items := make([]string, 0, len(gks))
+	for _, gk := range gks {
+		items = append(items, gk.String())
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
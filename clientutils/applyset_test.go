@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("ApplySet", func() {
+	const fieldManager = "my-manager"
+
+	var (
+		ctx    context.Context
+		c      client.Client
+		parent *corev1.ConfigMap
+		cmGK   schema.GroupKind
+		secGK  schema.GroupKind
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		parent = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "parent"}}
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)).
+			WithObjects(parent.DeepCopy()).
+			Build()
+		cmGK = schema.GroupKind{Kind: "ConfigMap"}
+		secGK = schema.GroupKind{Kind: "Secret"}
+	})
+
+	configMap := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: name},
+			Data:       map[string]string{"foo": "bar"},
+		}
+	}
+	secret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: name},
+			StringData: map[string]string{"foo": "bar"},
+		}
+	}
+	ref := func(gk schema.GroupKind, name string) ObjectRef {
+		return ObjectRef{GroupKind: gk, Key: client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: name}}
+	}
+
+	It("should compute a stable id from the parent", func() {
+		id := ApplySetID(ref(cmGK, "parent"))
+		Expect(id).To(HavePrefix("applyset-"))
+		Expect(id).To(HaveSuffix("-v1"))
+		Expect(ApplySetID(ref(cmGK, "parent"))).To(Equal(id))
+		Expect(ApplySetID(ref(secGK, "parent"))).NotTo(Equal(id))
+	})
+
+	It("should stamp the members and the parent and prune removed members", func() {
+		s, err := NewApplySet(c, parent, fieldManager)
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := s.Apply(ctx, []client.Object{configMap("cm-1"), configMap("cm-2"), secret("secret-1")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Applied).To(Equal(NewObjectRefSet(ref(cmGK, "cm-1"), ref(cmGK, "cm-2"), ref(secGK, "secret-1"))))
+		Expect(res.Pruned).To(BeEmpty())
+
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "cm-1"}, cm)).To(Succeed())
+		Expect(cm.Labels).To(HaveKeyWithValue(ApplySetPartOfLabel, s.ID()))
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(parent), parent)).To(Succeed())
+		Expect(parent.Labels).To(HaveKeyWithValue(ApplySetParentIDLabel, s.ID()))
+		Expect(parent.Annotations).To(HaveKeyWithValue(ApplySetGroupKindsAnnotation, "ConfigMap,Secret"))
+
+		By("applying without the second config map and the secret")
+		res, err = s.Apply(ctx, []client.Object{configMap("cm-1")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Pruned).To(Equal([]ObjectRef{ref(cmGK, "cm-2"), ref(secGK, "secret-1")}))
+
+		Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "cm-2"}, &corev1.ConfigMap{}))).To(BeTrue())
+		Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "secret-1"}, &corev1.Secret{}))).To(BeTrue())
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "cm-1"}, &corev1.ConfigMap{})).To(Succeed())
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(parent), parent)).To(Succeed())
+		Expect(parent.Annotations).To(HaveKeyWithValue(ApplySetGroupKindsAnnotation, "ConfigMap"))
+	})
+
+	It("should not prune objects that are no members", func() {
+		Expect(c.Create(ctx, configMap("unrelated"))).To(Succeed())
+
+		s, err := NewApplySet(c, parent, fieldManager)
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := s.Apply(ctx, []client.Object{configMap("cm-1")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Pruned).To(BeEmpty())
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "unrelated"}, &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should list but not prune members on a dry run", func() {
+		s, err := NewApplySet(c, parent, fieldManager)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Apply(ctx, []client.Object{configMap("cm-1"), secret("secret-1")})
+		Expect(err).NotTo(HaveOccurred())
+
+		prunable, err := s.Prunable(ctx, []client.Object{configMap("cm-1")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prunable).To(Equal([]ObjectRef{ref(secGK, "secret-1")}))
+
+		res, err := s.Apply(ctx, []client.Object{configMap("cm-1")}, client.DryRunAll)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Pruned).To(Equal([]ObjectRef{ref(secGK, "secret-1")}))
+
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "secret-1"}, &corev1.Secret{})).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(parent), parent)).To(Succeed())
+		Expect(parent.Annotations).To(HaveKeyWithValue(ApplySetGroupKindsAnnotation, "ConfigMap,Secret"))
+	})
+
+	It("should error if the parent belongs to a different apply set", func() {
+		parent.Labels = map[string]string{ApplySetParentIDLabel: "applyset-other-v1"}
+		Expect(c.Update(ctx, parent)).To(Succeed())
+
+		s, err := NewApplySet(c, parent, fieldManager)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = s.Apply(ctx, []client.Object{configMap("cm-1")})
+		Expect(err).To(MatchError(ContainSubstring("unexpected id")))
+	})
+})
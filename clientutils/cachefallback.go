@@ -0,0 +1,446 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultStaleTimeout is the default duration written resource versions are tracked for FallbackOnStale.
+const DefaultStaleTimeout = 30 * time.Second
+
+// FallbackReason is the reason a CacheFallbackClient fell back to the live API reader.
+type FallbackReason string
+
+const (
+	// FallbackReasonNotFound is used when the cache did not contain the requested object.
+	FallbackReasonNotFound FallbackReason = "NotFound"
+	// FallbackReasonUncached is used when the cache does not serve the requested kind or is not started yet.
+	FallbackReasonUncached FallbackReason = "Uncached"
+	// FallbackReasonStale is used when the cache contained an older version of an object written by the client.
+	FallbackReasonStale FallbackReason = "Stale"
+)
+
+// FallbackRecorder records fallbacks of a CacheFallbackClient to the live API reader.
+type FallbackRecorder interface {
+	// RecordFallback records a fallback for the object of the given kind and key. For list calls, the key
+	// only contains the namespace listed in, if any.
+	RecordFallback(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, reason FallbackReason)
+}
+
+// LogFallbackRecorder is a FallbackRecorder that logs each fallback using the logger of the context at
+// verbosity level 1.
+type LogFallbackRecorder struct{}
+
+// RecordFallback implements FallbackRecorder.
+func (LogFallbackRecorder) RecordFallback(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, reason FallbackReason) {
+	log.FromContext(ctx).V(1).Info("Falling back to live API reader",
+		"GroupVersionKind", gvk,
+		"Key", key,
+		"Reason", reason,
+	)
+}
+
+// MetricsFallbackRecorder is a FallbackRecorder that counts fallbacks by group, kind and reason in the
+// controller_utils_client_cache_fallbacks_total metric.
+type MetricsFallbackRecorder struct {
+	// Counter is the counter vector with the labels 'group', 'kind' and 'reason'.
+	Counter *prometheus.CounterVec
+}
+
+// NewMetricsFallbackRecorder creates a new MetricsFallbackRecorder and registers its counter with the given
+// prometheus.Registerer, e.g. the metrics.Registry of controller-runtime.
+// If the counter is already registered, the registered counter is reused.
+func NewMetricsFallbackRecorder(reg prometheus.Registerer) (*MetricsFallbackRecorder, error) {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: "controller_utils_client",
+		Name:      "cache_fallbacks_total",
+		Help:      "Total number of reads that fell back from the cache to the live API reader.",
+	}, []string{"group", "kind", "reason"})
+	if err := reg.Register(counter); err != nil {
+		var alreadyRegisteredErr prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegisteredErr) {
+			return nil, err
+		}
+		existing, ok := alreadyRegisteredErr.ExistingCollector.(*prometheus.CounterVec)
+		if !ok {
+			return nil, err
+		}
+		counter = existing
+	}
+	return &MetricsFallbackRecorder{Counter: counter}, nil
+}
+
+// RecordFallback implements FallbackRecorder.
+func (r *MetricsFallbackRecorder) RecordFallback(_ context.Context, gvk schema.GroupVersionKind, _ client.ObjectKey, reason FallbackReason) {
+	r.Counter.WithLabelValues(gvk.Group, gvk.Kind, string(reason)).Inc()
+}
+
+// FallbackOptions are options for CacheFallbackClient.
+type FallbackOptions struct {
+	// OnNotFound falls back if the cache does not contain the requested object.
+	OnNotFound bool
+	// OnUncached falls back if the cache does not serve the requested kind (e.g. because
+	// cache.Options.ReaderFailOnMissingInformer is set) or is not started yet.
+	OnUncached bool
+	// OnStale falls back if the cache contains an older version of an object than the client wrote.
+	OnStale bool
+	// StaleTimeout is the duration written resource versions are tracked for OnStale.
+	// If zero, DefaultStaleTimeout is used.
+	StaleTimeout time.Duration
+	// UncachedKinds are always read from the live API reader. Reads of these kinds are not recorded.
+	UncachedKinds []schema.GroupKind
+	// Recorders record each fallback. If empty, a LogFallbackRecorder is used.
+	Recorders []FallbackRecorder
+}
+
+// ApplyToFallback implements FallbackOption.
+func (o *FallbackOptions) ApplyToFallback(o2 *FallbackOptions) {
+	if o.OnNotFound {
+		o2.OnNotFound = true
+	}
+	if o.OnUncached {
+		o2.OnUncached = true
+	}
+	if o.OnStale {
+		o2.OnStale = true
+	}
+	if o.StaleTimeout != 0 {
+		o2.StaleTimeout = o.StaleTimeout
+	}
+	o2.UncachedKinds = append(o2.UncachedKinds, o.UncachedKinds...)
+	o2.Recorders = append(o2.Recorders, o.Recorders...)
+}
+
+// ApplyOptions applies all FallbackOption to this FallbackOptions.
+func (o *FallbackOptions) ApplyOptions(opts []FallbackOption) {
+	for _, opt := range opts {
+		opt.ApplyToFallback(o)
+	}
+}
+
+// FallbackOption is an option to CacheFallbackClient.
+type FallbackOption interface {
+	// ApplyToFallback modifies the underlying FallbackOptions.
+	ApplyToFallback(o *FallbackOptions)
+}
+
+// FallbackOnNotFound sets FallbackOptions.OnNotFound.
+type FallbackOnNotFound struct{}
+
+// ApplyToFallback implements FallbackOption.
+func (FallbackOnNotFound) ApplyToFallback(o *FallbackOptions) {
+	o.OnNotFound = true
+}
+
+// FallbackOnUncached sets FallbackOptions.OnUncached.
+type FallbackOnUncached struct{}
+
+// ApplyToFallback implements FallbackOption.
+func (FallbackOnUncached) ApplyToFallback(o *FallbackOptions) {
+	o.OnUncached = true
+}
+
+// FallbackOnStale sets FallbackOptions.OnStale.
+type FallbackOnStale struct{}
+
+// ApplyToFallback implements FallbackOption.
+func (FallbackOnStale) ApplyToFallback(o *FallbackOptions) {
+	o.OnStale = true
+}
+
+// StaleTimeout sets FallbackOptions.StaleTimeout.
+type StaleTimeout time.Duration
+
+// ApplyToFallback implements FallbackOption.
+func (t StaleTimeout) ApplyToFallback(o *FallbackOptions) {
+	o.StaleTimeout = time.Duration(t)
+}
+
+// UncachedKinds adds to FallbackOptions.UncachedKinds.
+type UncachedKinds []schema.GroupKind
+
+// ApplyToFallback implements FallbackOption.
+func (k UncachedKinds) ApplyToFallback(o *FallbackOptions) {
+	o.UncachedKinds = append(o.UncachedKinds, k...)
+}
+
+// FallbackRecorders adds to FallbackOptions.Recorders.
+type FallbackRecorders []FallbackRecorder
+
+// ApplyToFallback implements FallbackOption.
+func (r FallbackRecorders) ApplyToFallback(o *FallbackOptions) {
+	o.Recorders = append(o.Recorders, r...)
+}
+
+type writtenVersion struct {
+	resourceVersion string
+	writtenAt       time.Time
+}
+
+type cacheFallbackClient struct {
+	client.Client
+	apiReader client.Reader
+
+	onNotFound    bool
+	onUncached    bool
+	onStale       bool
+	staleTimeout  time.Duration
+	uncachedKinds sets.Set[schema.GroupKind]
+	recorders     []FallbackRecorder
+
+	mu      sync.Mutex
+	written map[ObjectRef]writtenVersion
+}
+
+// CacheFallbackClient returns a client.Client that reads using the given client, which usually reads from
+// an informer cache, and falls back to the given live API reader according to the FallbackOptions.
+// All other operations are done using the given client.
+//
+// If none of FallbackOnNotFound, FallbackOnUncached and FallbackOnStale is specified, all of them are enabled.
+//
+// For FallbackOnStale, the client tracks the resource versions of objects it created, updated or patched
+// (including their status) for the FallbackOptions.StaleTimeout. Server-side applies via Patch with client.Apply
+// are tracked like other patches, applies via Apply are not tracked.
+// If the cache returns an older resource version of such an object, the object is read from the live API reader.
+// Resource versions are compared numerically, as issued by etcd-backed API servers; other resource versions are
+// only considered current if they are equal.
+func CacheFallbackClient(c client.Client, apiReader client.Reader, opts ...FallbackOption) client.Client {
+	o := &FallbackOptions{}
+	o.ApplyOptions(opts)
+	if !o.OnNotFound && !o.OnUncached && !o.OnStale {
+		o.OnNotFound, o.OnUncached, o.OnStale = true, true, true
+	}
+	staleTimeout := o.StaleTimeout
+	if staleTimeout == 0 {
+		staleTimeout = DefaultStaleTimeout
+	}
+	recorders := o.Recorders
+	if len(recorders) == 0 {
+		recorders = []FallbackRecorder{LogFallbackRecorder{}}
+	}
+
+	return &cacheFallbackClient{
+		Client:        c,
+		apiReader:     apiReader,
+		onNotFound:    o.OnNotFound,
+		onUncached:    o.OnUncached,
+		onStale:       o.OnStale,
+		staleTimeout:  staleTimeout,
+		uncachedKinds: sets.New(o.UncachedKinds...),
+		recorders:     recorders,
+		written:       make(map[ObjectRef]writtenVersion),
+	}
+}
+
+func isUncachedError(err error) bool {
+	var notCachedErr *cache.ErrResourceNotCached
+	var notStartedErr *cache.ErrCacheNotStarted
+	return errors.As(err, &notCachedErr) || errors.As(err, &notStartedErr)
+}
+
+func (c *cacheFallbackClient) record(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, reason FallbackReason) {
+	for _, recorder := range c.recorders {
+		recorder.RecordFallback(ctx, gvk, key, reason)
+	}
+}
+
+func (c *cacheFallbackClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return err
+	}
+	if c.uncachedKinds.Has(gvk.GroupKind()) {
+		return c.apiReader.Get(ctx, key, obj, opts...)
+	}
+
+	var reason FallbackReason
+	err = c.Client.Get(ctx, key, obj, opts...)
+	switch {
+	case err == nil:
+		if !c.onStale || !c.isStale(ObjectRef{GroupKind: gvk.GroupKind(), Key: key}, obj.GetResourceVersion()) {
+			return nil
+		}
+		reason = FallbackReasonStale
+		resetObject(obj, gvk)
+	case apierrors.IsNotFound(err) && c.onNotFound:
+		reason = FallbackReasonNotFound
+	case isUncachedError(err) && c.onUncached:
+		reason = FallbackReasonUncached
+	default:
+		return err
+	}
+
+	c.record(ctx, gvk, key, reason)
+	return c.apiReader.Get(ctx, key, obj, opts...)
+}
+
+// resetObject resets the given object to its zero value, keeping only its group version kind.
+// This prevents fields only present in the cached version from surviving a subsequent read.
+func resetObject(obj client.Object, gvk schema.GroupVersionKind) {
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+}
+
+func (c *cacheFallbackClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	gvk, err := c.GroupVersionKindFor(list)
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	if c.uncachedKinds.Has(gvk.GroupKind()) {
+		return c.apiReader.List(ctx, list, opts...)
+	}
+
+	err = c.Client.List(ctx, list, opts...)
+	if err == nil || !isUncachedError(err) || !c.onUncached {
+		return err
+	}
+
+	o := &client.ListOptions{}
+	o.ApplyOptions(opts)
+	c.record(ctx, gvk, client.ObjectKey{Namespace: o.Namespace}, FallbackReasonUncached)
+	return c.apiReader.List(ctx, list, opts...)
+}
+
+// isStale reports whether the given cached resource version is older than the one written for ref.
+// Entries for which the cache caught up or whose stale timeout passed are removed.
+func (c *cacheFallbackClient) isStale(ref ObjectRef, cachedResourceVersion string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	written, ok := c.written[ref]
+	if !ok {
+		return false
+	}
+	if time.Since(written.writtenAt) > c.staleTimeout || !resourceVersionOlder(cachedResourceVersion, written.resourceVersion) {
+		delete(c.written, ref)
+		return false
+	}
+	return true
+}
+
+func resourceVersionOlder(rv, other string) bool {
+	if rv == other {
+		return false
+	}
+	rvNum, err := strconv.ParseUint(rv, 10, 64)
+	if err != nil {
+		return true
+	}
+	otherNum, err := strconv.ParseUint(other, 10, 64)
+	if err != nil {
+		return true
+	}
+	return rvNum < otherNum
+}
+
+// track records the resource version of the given object after it was written.
+func (c *cacheFallbackClient) track(obj client.Object) {
+	if !c.onStale || obj.GetResourceVersion() == "" {
+		return
+	}
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for ref, written := range c.written {
+		if now.Sub(written.writtenAt) > c.staleTimeout {
+			delete(c.written, ref)
+		}
+	}
+	ref := ObjectRef{GroupKind: gvk.GroupKind(), Key: client.ObjectKeyFromObject(obj)}
+	c.written[ref] = writtenVersion{resourceVersion: obj.GetResourceVersion(), writtenAt: now}
+}
+
+func (c *cacheFallbackClient) untrack(obj client.Object) {
+	if !c.onStale {
+		return
+	}
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.written, ObjectRef{GroupKind: gvk.GroupKind(), Key: client.ObjectKeyFromObject(obj)})
+}
+
+func (c *cacheFallbackClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.track(obj)
+	return nil
+}
+
+func (c *cacheFallbackClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.track(obj)
+	return nil
+}
+
+func (c *cacheFallbackClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	c.track(obj)
+	return nil
+}
+
+func (c *cacheFallbackClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.untrack(obj)
+	return nil
+}
+
+func (c *cacheFallbackClient) Status() client.SubResourceWriter {
+	return &cacheFallbackStatusWriter{c.Client.Status(), c}
+}
+
+type cacheFallbackStatusWriter struct {
+	client.SubResourceWriter
+	c *cacheFallbackClient
+}
+
+func (w *cacheFallbackStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if err := w.SubResourceWriter.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	w.c.track(obj)
+	return nil
+}
+
+func (w *cacheFallbackStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if err := w.SubResourceWriter.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	w.c.track(obj)
+	return nil
+}
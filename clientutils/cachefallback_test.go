@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+type fallback struct {
+	gvk    schema.GroupVersionKind
+	key    client.ObjectKey
+	reason FallbackReason
+}
+
+type fallbackRecorder struct {
+	fallbacks []fallback
+}
+
+func (r *fallbackRecorder) RecordFallback(_ context.Context, gvk schema.GroupVersionKind, key client.ObjectKey, reason FallbackReason) {
+	r.fallbacks = append(r.fallbacks, fallback{gvk, key, reason})
+}
+
+var _ = Describe("CacheFallbackClient", func() {
+	var (
+		ctx        context.Context
+		cacheC     client.Client
+		liveC      client.Client
+		recorder   *fallbackRecorder
+		cm         *corev1.ConfigMap
+		cmKey      client.ObjectKey
+		cmGVK      schema.GroupVersionKind
+		uncachedCM bool
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		recorder = &fallbackRecorder{}
+		uncachedCM = false
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-cm"}}
+		cmKey = client.ObjectKeyFromObject(cm)
+		cmGVK = corev1.SchemeGroupVersion.WithKind("ConfigMap")
+
+		notCached := func() error {
+			if uncachedCM {
+				return &cache.ErrResourceNotCached{}
+			}
+			return nil
+		}
+		cacheC = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if err := notCached(); err != nil {
+						return err
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if err := notCached(); err != nil {
+						return err
+					}
+					return c.List(ctx, list, opts...)
+				},
+			}).
+			Build()
+		liveC = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	})
+
+	newClient := func(opts ...FallbackOption) client.Client {
+		return CacheFallbackClient(ReaderClient(cacheC, liveC), liveC, append(opts, FallbackRecorders{recorder})...)
+	}
+
+	It("should read from the cache if it contains the object", func() {
+		Expect(cacheC.Create(ctx, cm.DeepCopy())).To(Succeed())
+
+		Expect(newClient().Get(ctx, cmKey, &corev1.ConfigMap{})).To(Succeed())
+		Expect(recorder.fallbacks).To(BeEmpty())
+	})
+
+	It("should fall back if the cache does not contain the object", func() {
+		Expect(liveC.Create(ctx, cm.DeepCopy())).To(Succeed())
+
+		Expect(newClient().Get(ctx, cmKey, &corev1.ConfigMap{})).To(Succeed())
+		Expect(recorder.fallbacks).To(Equal([]fallback{{cmGVK, cmKey, FallbackReasonNotFound}}))
+	})
+
+	It("should not fall back on not found if not configured", func() {
+		Expect(liveC.Create(ctx, cm.DeepCopy())).To(Succeed())
+
+		err := newClient(FallbackOnStale{}).Get(ctx, cmKey, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(recorder.fallbacks).To(BeEmpty())
+	})
+
+	It("should fall back if the cache does not serve the kind", func() {
+		uncachedCM = true
+		Expect(liveC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		c := newClient()
+
+		Expect(c.Get(ctx, cmKey, &corev1.ConfigMap{})).To(Succeed())
+		list := &corev1.ConfigMapList{}
+		Expect(c.List(ctx, list, client.InNamespace(corev1.NamespaceDefault))).To(Succeed())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(recorder.fallbacks).To(Equal([]fallback{
+			{cmGVK, cmKey, FallbackReasonUncached},
+			{cmGVK, client.ObjectKey{Namespace: corev1.NamespaceDefault}, FallbackReasonUncached},
+		}))
+	})
+
+	It("should always read uncached kinds from the live reader without recording", func() {
+		Expect(liveC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		Expect(cacheC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		Expect(liveC.Delete(ctx, cm.DeepCopy())).To(Succeed())
+
+		c := newClient(UncachedKinds{cmGVK.GroupKind()})
+		Expect(apierrors.IsNotFound(c.Get(ctx, cmKey, &corev1.ConfigMap{}))).To(BeTrue())
+		Expect(recorder.fallbacks).To(BeEmpty())
+	})
+
+	It("should fall back if the cache contains an older version of a written object", func() {
+		Expect(liveC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		Expect(cacheC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		c := newClient()
+
+		written := &corev1.ConfigMap{}
+		Expect(liveC.Get(ctx, cmKey, written)).To(Succeed())
+		written.Data = map[string]string{"foo": "bar"}
+		Expect(c.Update(ctx, written)).To(Succeed())
+
+		actual := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, cmKey, actual)).To(Succeed())
+		Expect(actual.Data).To(Equal(map[string]string{"foo": "bar"}))
+		Expect(recorder.fallbacks).To(Equal([]fallback{{cmGVK, cmKey, FallbackReasonStale}}))
+
+		By("updating the cache")
+		cached := &corev1.ConfigMap{}
+		Expect(cacheC.Get(ctx, cmKey, cached)).To(Succeed())
+		cached.Data = written.Data
+		Expect(cacheC.Update(ctx, cached)).To(Succeed())
+		Expect(cached.ResourceVersion).To(Equal(written.ResourceVersion))
+
+		Expect(c.Get(ctx, cmKey, &corev1.ConfigMap{})).To(Succeed())
+		Expect(recorder.fallbacks).To(HaveLen(1))
+	})
+
+	It("should track server-side applies via patch", func() {
+		Expect(liveC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		Expect(cacheC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		c := newClient()
+
+		applied := cm.DeepCopy()
+		applied.SetGroupVersionKind(cmGVK)
+		applied.Data = map[string]string{"foo": "bar"}
+		Expect(c.Patch(ctx, applied, client.Apply, client.FieldOwner("my-manager"), client.ForceOwnership)).To(Succeed())
+
+		actual := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, cmKey, actual)).To(Succeed())
+		Expect(actual.Data).To(Equal(map[string]string{"foo": "bar"}))
+		Expect(recorder.fallbacks).To(Equal([]fallback{{cmGVK, cmKey, FallbackReasonStale}}))
+	})
+
+	It("should count fallbacks with the metrics recorder", func() {
+		reg := prometheus.NewRegistry()
+		metricsRecorder, err := NewMetricsFallbackRecorder(reg)
+		Expect(err).NotTo(HaveOccurred())
+		sameRecorder, err := NewMetricsFallbackRecorder(reg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sameRecorder.Counter).To(BeIdenticalTo(metricsRecorder.Counter))
+
+		Expect(liveC.Create(ctx, cm.DeepCopy())).To(Succeed())
+		c := CacheFallbackClient(ReaderClient(cacheC, liveC), liveC, FallbackRecorders{metricsRecorder})
+		Expect(c.Get(ctx, cmKey, &corev1.ConfigMap{})).To(Succeed())
+
+		Expect(testutil.ToFloat64(metricsRecorder.Counter.WithLabelValues("", "ConfigMap", string(FallbackReasonNotFound)))).To(Equal(1.0))
+		Expect(testutil.GatherAndCount(reg, "controller_utils_client_cache_fallbacks_total")).To(Equal(1))
+	})
+})
@@ -6,10 +6,13 @@
 package clientutils
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"reflect"
+	"slices"
 
 	"github.com/ironcore-dev/controller-utils/metautils"
 	"github.com/ironcore-dev/controller-utils/unstructuredutils"
@@ -74,14 +77,21 @@ func CreateMultipleFromFile(ctx context.Context, c client.Client, filename strin
 }
 
 // CreateMultiple creates multiple objects using the given client and options.
+// Any MultipleOption (e.g. OrderByKind, MaxConcurrency or ContinueOnError) given in opts controls how the objects
+// are processed.
 func CreateMultiple(ctx context.Context, c client.Client, objs []client.Object, opts ...client.CreateOption) error {
-	for _, obj := range objs {
-		if err := c.Create(ctx, obj, opts...); err != nil {
+	o, opts := splitMultipleOptions(opts)
+	return o.run(ctx, c, batch{
+		verb: VerbCreate,
+		objs: objs,
+		do: func(ctx context.Context, i int) error {
+			return c.Create(ctx, objs[i], opts...)
+		},
+		wrap: func(i int, err error) error {
 			return fmt.Errorf("error creating object %s: %w",
-				client.ObjectKeyFromObject(obj), err)
-		}
-	}
-	return nil
+				client.ObjectKeyFromObject(objs[i]), err)
+		},
+	})
 }
 
 // GetRequest is a request to get an object with the given key and object (that is later used to write the result into).
@@ -128,11 +138,25 @@ type getRequestTypedKey struct {
 	objectKey client.ObjectKey
 }
 
+func compareGetRequestTypedKeys(a, b getRequestTypedKey) int {
+	if c := CompareObjectKeys(a.objectKey, b.objectKey); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.typ.PkgPath()+"."+a.typ.Name(), b.typ.PkgPath()+"."+b.typ.Name())
+}
+
 type getRequestUnstructuredKey struct {
 	gvk       schema.GroupVersionKind
 	objectKey client.ObjectKey
 }
 
+func compareGetRequestUnstructuredKeys(a, b getRequestUnstructuredKey) int {
+	if c := CompareObjectKeys(a.objectKey, b.objectKey); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.gvk.String(), b.gvk.String())
+}
+
 // GetRequestSet is a set of GetRequest.
 //
 // Internally, the objects are differentiated by either being typed or unstructured.
@@ -215,28 +239,63 @@ func (s *GetRequestSet) Delete(items ...GetRequest) {
 // Iterate iterates through the get requests of this set using the given function.
 // If the function returns true (i.e. stop), the iteration is canceled.
 func (s *GetRequestSet) Iterate(f func(GetRequest) (cont bool)) {
-	for k, v := range s.typed {
-		if cont := f(GetRequest{Key: k.objectKey, Object: v}); !cont {
-			return
-		}
-	}
-	for k, v := range s.unstructured {
-		if cont := f(GetRequest{Key: k.objectKey, Object: v}); !cont {
+	for req := range s.All() {
+		if cont := f(req); !cont {
 			return
 		}
 	}
 }
 
 // List returns all GetRequests of this set.
+//
+// The result is sorted: typed objects come first, followed by unstructured objects, each sorted by their
+// client.ObjectKey (see CompareObjectKeys) and then by their type respectively group version kind.
 func (s *GetRequestSet) List() []GetRequest {
 	res := make([]GetRequest, 0, s.Len())
-	s.Iterate(func(request GetRequest) (cont bool) {
-		res = append(res, request)
-		return true
-	})
+	for _, k := range setSortedKeys(s.typed, compareGetRequestTypedKeys) {
+		res = append(res, GetRequest{Key: k.objectKey, Object: s.typed[k]})
+	}
+	for _, k := range setSortedKeys(s.unstructured, compareGetRequestUnstructuredKeys) {
+		res = append(res, GetRequest{Key: k.objectKey, Object: s.unstructured[k]})
+	}
 	return res
 }
 
+// All returns an iterator over the GetRequests of this set in the order of List.
+func (s *GetRequestSet) All() iter.Seq[GetRequest] {
+	return slices.Values(s.List())
+}
+
+// Union returns a new set containing the items of both s and s2.
+// If an item is present in both, the object of s is used.
+func (s *GetRequestSet) Union(s2 *GetRequestSet) *GetRequestSet {
+	return &GetRequestSet{
+		typed:        setUnion(s.typed, s2.typed),
+		unstructured: setUnion(s.unstructured, s2.unstructured),
+	}
+}
+
+// Intersection returns a new set containing the items present in both s and s2.
+func (s *GetRequestSet) Intersection(s2 *GetRequestSet) *GetRequestSet {
+	return &GetRequestSet{
+		typed:        setIntersection(s.typed, s2.typed),
+		unstructured: setIntersection(s.unstructured, s2.unstructured),
+	}
+}
+
+// Difference returns a new set containing the items of s that are not present in s2.
+func (s *GetRequestSet) Difference(s2 *GetRequestSet) *GetRequestSet {
+	return &GetRequestSet{
+		typed:        setDifference(s.typed, s2.typed),
+		unstructured: setDifference(s.unstructured, s2.unstructured),
+	}
+}
+
+// Equal reports whether s and s2 contain the same items.
+func (s *GetRequestSet) Equal(s2 *GetRequestSet) bool {
+	return setEqual(s.typed, s2.typed) && setEqual(s.unstructured, s2.unstructured)
+}
+
 // NewGetRequestSet creates a new set of GetRequest.
 //
 // Internally, the objects are differentiated by either being typed or unstructured.
@@ -255,7 +314,7 @@ func NewGetRequestSet(items ...GetRequest) *GetRequestSet {
 
 // GetMultipleFromFile creates multiple objects by reading the given file as unstructured objects and then creating
 // the read objects using the given client and options.
-func GetMultipleFromFile(ctx context.Context, c client.Client, filename string) ([]unstructured.Unstructured, error) {
+func GetMultipleFromFile(ctx context.Context, c client.Client, filename string, opts ...MultipleOption) ([]unstructured.Unstructured, error) {
 	objs, err := unstructuredutils.ReadFile(filename)
 	if err != nil {
 		return nil, err
@@ -270,7 +329,7 @@ func GetMultipleFromFile(ctx context.Context, c client.Client, filename string)
 		})
 	}
 
-	if err := GetMultiple(ctx, c, reqs); err != nil {
+	if err := GetMultiple(ctx, c, reqs, opts...); err != nil {
 		return nil, err
 	}
 
@@ -278,13 +337,23 @@ func GetMultipleFromFile(ctx context.Context, c client.Client, filename string)
 }
 
 // GetMultiple gets multiple objects using the given client. The results are written back into the given GetRequest.
-func GetMultiple(ctx context.Context, c client.Client, reqs []GetRequest) error {
-	for _, req := range reqs {
-		if err := c.Get(ctx, req.Key, req.Object); err != nil {
-			return fmt.Errorf("error getting object %s: %w", req.Key, err)
-		}
-	}
-	return nil
+// The given MultipleOption (e.g. MaxConcurrency or ContinueOnError) control how the requests are processed.
+func GetMultiple(ctx context.Context, c client.Client, reqs []GetRequest, opts ...MultipleOption) error {
+	o := &MultipleOptions{}
+	o.ApplyOptions(opts)
+	return o.run(ctx, c, batch{
+		verb: VerbGet,
+		objs: ObjectsFromGetRequests(reqs),
+		key: func(i int) client.ObjectKey {
+			return reqs[i].Key
+		},
+		do: func(ctx context.Context, i int) error {
+			return c.Get(ctx, reqs[i].Key, reqs[i].Object)
+		},
+		wrap: func(i int, err error) error {
+			return fmt.Errorf("error getting object %s: %w", reqs[i].Key, err)
+		},
+	})
 }
 
 // apply is a PatchProvider always providing a server-side apply patch.
@@ -354,20 +423,28 @@ func ObjectsFromPatchRequests(reqs []PatchRequest) []client.Object {
 }
 
 // PatchMultiple executes multiple PatchRequest with the given client.PatchOption.
+// Any MultipleOption (e.g. OrderByKind, MaxConcurrency or ContinueOnError) given in opts controls how the requests
+// are processed.
 func PatchMultiple(ctx context.Context, c client.Client, reqs []PatchRequest, opts ...client.PatchOption) error {
-	for _, req := range reqs {
-		if err := c.Patch(ctx, req.Object, req.Patch, opts...); err != nil {
+	o, opts := splitMultipleOptions(opts)
+	return o.run(ctx, c, batch{
+		verb: VerbPatch,
+		objs: ObjectsFromPatchRequests(reqs),
+		do: func(ctx context.Context, i int) error {
+			return c.Patch(ctx, reqs[i].Object, reqs[i].Patch, opts...)
+		},
+		wrap: func(i int, err error) error {
 			return fmt.Errorf("error patching object %s: %w",
-				client.ObjectKeyFromObject(req.Object),
+				client.ObjectKeyFromObject(reqs[i].Object),
 				err,
 			)
-		}
-	}
-	return nil
+		},
+	})
 }
 
-// PatchMultipleFromFile patches all objects from the given filename using the patchFor function.
-// The returned unstructured.Unstructured objects contain the result of applying them.
+// PatchMultipleFromFile patches all objects from the given filename using the patches of the given PatchProvider,
+// e.g. ApplyAll, MergeFromProvider, StrategicMergeFromProvider or JSONPatchProvider.
+// The returned unstructured.Unstructured objects contain the result of patching them.
 func PatchMultipleFromFile(
 	ctx context.Context,
 	c client.Client,
@@ -406,16 +483,24 @@ func DeleteMultipleFromFile(ctx context.Context, c client.Client, filename strin
 }
 
 // DeleteMultiple deletes multiple given client.Object objects using the given client.DeleteOption options.
+// Any MultipleOption (e.g. OrderByKind, MaxConcurrency or ContinueOnError) given in opts controls how the objects
+// are processed. When ordering by kind, objects are deleted in reverse order.
 func DeleteMultiple(ctx context.Context, c client.Client, objs []client.Object, opts ...client.DeleteOption) error {
-	for _, obj := range objs {
-		if err := c.Delete(ctx, obj, opts...); err != nil {
+	o, opts := splitMultipleOptions(opts)
+	return o.run(ctx, c, batch{
+		verb:    VerbDelete,
+		objs:    objs,
+		reverse: true,
+		do: func(ctx context.Context, i int) error {
+			return c.Delete(ctx, objs[i], opts...)
+		},
+		wrap: func(i int, err error) error {
 			return fmt.Errorf("error deleting object %s: %w",
-				client.ObjectKeyFromObject(obj),
+				client.ObjectKeyFromObject(objs[i]),
 				err,
 			)
-		}
-	}
-	return nil
+		},
+	})
 }
 
 // ListAndFilter is a shorthand for doing a client.Client.List followed by filtering the list's elements
@@ -579,34 +664,53 @@ func DeleteIfExists(ctx context.Context, c client.Client, obj client.Object, opt
 
 // DeleteMultipleIfExist deletes the given objects, if they exist. It returns any non apierrors.IsNotFound error
 // and any object that existed before issuing the delete request.
+// Any MultipleOption (e.g. OrderByKind, MaxConcurrency or ContinueOnError) given in opts controls how the objects
+// are processed. When ordering by kind, objects are deleted in reverse order.
 func DeleteMultipleIfExist(ctx context.Context, c client.Client, objs []client.Object, opts ...client.DeleteOption) (existed []client.Object, err error) {
+	o, opts := splitMultipleOptions(opts)
+	didExist := make([]bool, len(objs))
+	err = o.run(ctx, c, batch{
+		verb:    VerbDelete,
+		objs:    objs,
+		reverse: true,
+		do: func(ctx context.Context, i int) error {
+			ok, err := DeleteIfExists(ctx, c, objs[i], opts...)
+			didExist[i] = ok
+			return err
+		},
+		wrap: func(i int, err error) error {
+			return fmt.Errorf("[object %d]: error deleting %v: %w", i, objs[i], err)
+		},
+	})
+
 	for i, obj := range objs {
-		ok, err := DeleteIfExists(ctx, c, obj, opts...)
-		if err != nil {
-			return existed, fmt.Errorf("[object %d]: error deleting %v: %w", i, obj, err)
-		}
-		if ok {
-			obj := obj
+		if didExist[i] {
 			existed = append(existed, obj)
 		}
 	}
-	return existed, nil
+	return existed, err
 }
 
 // PatchAddFinalizer issues a patch to add the given finalizer to the given object.
 // The client.Patch method will be called regardless whether the finalizer was already present or not.
+// The patch uses optimistic locking, so concurrent changes to the finalizers are not overridden.
+// On conflict, the object is re-fetched and the patch is retried (see PatchWithRetry).
 func PatchAddFinalizer(ctx context.Context, c client.Client, obj client.Object, finalizer string) error {
-	baseObj := obj.DeepCopyObject().(client.Object)
-	controllerutil.AddFinalizer(obj, finalizer)
-	return c.Patch(ctx, obj, client.MergeFrom(baseObj))
+	return PatchWithRetry(ctx, c, obj, func() error {
+		controllerutil.AddFinalizer(obj, finalizer)
+		return nil
+	}, OptimisticLock{})
 }
 
 // PatchRemoveFinalizer issues a patch to remove the given finalizer from the given object.
 // The client.Patch method will be called regardless whether the finalizer was already gone or not.
+// The patch uses optimistic locking, so concurrent changes to the finalizers are not overridden.
+// On conflict, the object is re-fetched and the patch is retried (see PatchWithRetry).
 func PatchRemoveFinalizer(ctx context.Context, c client.Client, obj client.Object, finalizer string) error {
-	baseObj := obj.DeepCopyObject().(client.Object)
-	controllerutil.RemoveFinalizer(obj, finalizer)
-	return c.Patch(ctx, obj, client.MergeFrom(baseObj))
+	return PatchWithRetry(ctx, c, obj, func() error {
+		controllerutil.RemoveFinalizer(obj, finalizer)
+		return nil
+	}, OptimisticLock{})
 }
 
 // PatchEnsureFinalizer checks if the given object has the given finalizer and, if not, issues a patch request
@@ -333,6 +333,35 @@ var _ = Describe("Clientutils", func() {
 				s := NewGetRequestSet(GetRequestFromObject(cm), GetRequestFromObject(uPod))
 				Expect(s.List()).To(ConsistOf(GetRequestFromObject(cm), GetRequestFromObject(uPod)))
 			})
+
+			It("should list typed before unstructured entries, each sorted by key", func() {
+				s := NewGetRequestSet(GetRequestFromObject(uPod), GetRequestFromObject(secret), GetRequestFromObject(cm))
+				Expect(s.List()).To(Equal([]GetRequest{GetRequestFromObject(cm), GetRequestFromObject(secret), GetRequestFromObject(uPod)}))
+
+				var items []GetRequest
+				for req := range s.All() {
+					items = append(items, req)
+				}
+				Expect(items).To(Equal(s.List()))
+			})
+		})
+
+		Describe("Set algebra", func() {
+			It("should compute union, intersection and difference", func() {
+				s1 := NewGetRequestSet(GetRequestFromObject(cm), GetRequestFromObject(uPod))
+				s2 := NewGetRequestSet(GetRequestFromObject(uPod), GetRequestFromObject(secret))
+
+				Expect(s1.Union(s2).List()).To(ConsistOf(GetRequestFromObject(cm), GetRequestFromObject(uPod), GetRequestFromObject(secret)))
+				Expect(s1.Intersection(s2).List()).To(ConsistOf(GetRequestFromObject(uPod)))
+				Expect(s1.Difference(s2).List()).To(ConsistOf(GetRequestFromObject(cm)))
+				Expect(s1.Len()).To(Equal(2))
+			})
+
+			It("should report whether both sets contain the same items", func() {
+				s1 := NewGetRequestSet(GetRequestFromObject(cm), GetRequestFromObject(uPod))
+				Expect(s1.Equal(NewGetRequestSet(GetRequestFromObject(uPod), GetRequestFromObject(cm)))).To(BeTrue())
+				Expect(s1.Equal(NewGetRequestSet(GetRequestFromObject(cm)))).To(BeFalse())
+			})
 		})
 	})
 
@@ -826,14 +855,15 @@ var _ = Describe("Clientutils", func() {
 			cmWithFinalizer          *corev1.ConfigMap
 		)
 		BeforeEach(func() {
+			cm.ResourceVersion = "1"
 			cmWithFinalizer = cm.DeepCopy()
 			cmWithFinalizer.Finalizers = []string{finalizer}
 
 			var err error
-			addFinalizerPatchData, err = client.MergeFrom(cm).Data(cmWithFinalizer)
+			addFinalizerPatchData, err = client.MergeFromWithOptions(cm, client.MergeFromWithOptimisticLock{}).Data(cmWithFinalizer)
 			Expect(err).NotTo(HaveOccurred())
 
-			removeFinalizerPatchData, err = client.MergeFrom(cmWithFinalizer).Data(cm)
+			removeFinalizerPatchData, err = client.MergeFromWithOptions(cmWithFinalizer, client.MergeFromWithOptimisticLock{}).Data(cm)
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -0,0 +1,260 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// PatchStrategy is the strategy CreateOrUseAndPatchOf uses to patch an existing object.
+type PatchStrategy string
+
+const (
+	// PatchStrategyMergeFrom patches the mutated object using client.MergeFrom.
+	PatchStrategyMergeFrom PatchStrategy = "MergeFrom"
+	// PatchStrategyApply applies the desired state server-side.
+	PatchStrategyApply PatchStrategy = "Apply"
+)
+
+// CreateOrUseOptions are options for CreateOrUseAndPatchOf.
+type CreateOrUseOptions struct {
+	// DeleteSurplus deletes all matching objects that were not used.
+	DeleteSurplus bool
+	// PatchStrategy is the strategy to patch the object with. Defaults to PatchStrategyMergeFrom.
+	PatchStrategy PatchStrategy
+	// FieldManager is the field manager used for PatchStrategyApply.
+	FieldManager string
+	// Force forces field ownership for PatchStrategyApply.
+	Force bool
+}
+
+// ApplyToCreateOrUse implements CreateOrUseOption.
+func (o *CreateOrUseOptions) ApplyToCreateOrUse(o2 *CreateOrUseOptions) {
+	if o.DeleteSurplus {
+		o2.DeleteSurplus = true
+	}
+	if o.PatchStrategy != "" {
+		o2.PatchStrategy = o.PatchStrategy
+	}
+	if o.FieldManager != "" {
+		o2.FieldManager = o.FieldManager
+	}
+	if o.Force {
+		o2.Force = true
+	}
+}
+
+// ApplyOptions applies all CreateOrUseOption to this CreateOrUseOptions.
+func (o *CreateOrUseOptions) ApplyOptions(opts []CreateOrUseOption) {
+	for _, opt := range opts {
+		opt.ApplyToCreateOrUse(o)
+	}
+}
+
+// CreateOrUseOption is an option to CreateOrUseAndPatchOf.
+type CreateOrUseOption interface {
+	// ApplyToCreateOrUse modifies the underlying CreateOrUseOptions.
+	ApplyToCreateOrUse(o *CreateOrUseOptions)
+}
+
+// DeleteSurplus deletes all matching objects that were not used.
+type DeleteSurplus struct{}
+
+// ApplyToCreateOrUse implements CreateOrUseOption.
+func (DeleteSurplus) ApplyToCreateOrUse(o *CreateOrUseOptions) {
+	o.DeleteSurplus = true
+}
+
+// UseServerSideApply makes CreateOrUseAndPatchOf use PatchStrategyApply with the given field manager.
+type UseServerSideApply struct {
+	FieldManager string
+	Force        bool
+}
+
+// ApplyToCreateOrUse implements CreateOrUseOption.
+func (a UseServerSideApply) ApplyToCreateOrUse(o *CreateOrUseOptions) {
+	o.PatchStrategy = PatchStrategyApply
+	o.FieldManager = a.FieldManager
+	o.Force = a.Force
+}
+
+// IsOlder reports whether obj was created before other.
+// It can be used as lessFunc for CreateOrUseAndPatchOf to prefer the oldest object.
+func IsOlder[T client.Object](obj, other T) (bool, error) {
+	return obj.GetCreationTimestamp().Time.Before(other.GetCreationTimestamp().Time), nil
+}
+
+// CreateOrUseAndPatchOf traverses through the given objects and uses matchFunc to find matching objects.
+// If multiple objects match, lessFunc decides which one to use: the object for which lessFunc reports true
+// against all other matching objects wins. The winning object is copied, mutated with mutateFunc and patched.
+// If no object matches, a copy of template is mutated and created.
+// mutateFunc is optional, if none is specified no mutation will happen.
+//
+// With PatchStrategyApply (see UseServerSideApply), mutateFunc is called on an empty object of the type of template
+// that only carries the type information and key of the used object (none of the fields of template), so it has to
+// set the full desired state, which is then applied server-side. Otherwise, mutateFunc is called on a copy of the used object, which is then patched using
+// client.MergeFrom if it changed.
+//
+// CreateOrUseAndPatchOf returns the used object, the performed operation and the surplus objects, i.e. all
+// matching objects that were not used. With DeleteSurplus, these are deleted.
+func CreateOrUseAndPatchOf[T client.Object](
+	ctx context.Context,
+	c client.Client,
+	objects []T,
+	template T,
+	matchFunc func(obj T) (bool, error),
+	lessFunc func(obj, other T) (bool, error),
+	mutateFunc func(obj T) error,
+	opts ...CreateOrUseOption,
+) (T, controllerutil.OperationResult, []T, error) {
+	o := &CreateOrUseOptions{}
+	o.ApplyOptions(opts)
+
+	var (
+		zero    T
+		best    T
+		found   bool
+		surplus []T
+	)
+	for _, object := range objects {
+		match, err := matchFunc(object)
+		if err != nil {
+			return zero, controllerutil.OperationResultNone, nil, err
+		}
+		if !match {
+			continue
+		}
+
+		if !found {
+			best, found = object, true
+			continue
+		}
+
+		less, err := lessFunc(object, best)
+		if err != nil {
+			return zero, controllerutil.OperationResultNone, nil, err
+		}
+		if less {
+			surplus = append(surplus, best)
+			best = object
+		} else {
+			surplus = append(surplus, object)
+		}
+	}
+
+	var (
+		obj T
+		res controllerutil.OperationResult
+		err error
+	)
+	if found {
+		obj, res, err = useAndPatch(ctx, c, o, best, template, mutateFunc)
+	} else {
+		obj, res, err = createFromTemplate(ctx, c, o, template, mutateFunc)
+	}
+	if err != nil {
+		return zero, controllerutil.OperationResultNone, nil, err
+	}
+
+	if o.DeleteSurplus && len(surplus) > 0 {
+		surplusObjs := make([]client.Object, 0, len(surplus))
+		for _, object := range surplus {
+			surplusObjs = append(surplusObjs, object)
+		}
+		if _, err := DeleteMultipleIfExist(ctx, c, surplusObjs); err != nil {
+			return obj, res, surplus, fmt.Errorf("error deleting surplus objects: %w", err)
+		}
+	}
+	return obj, res, surplus, nil
+}
+
+func useAndPatch[T client.Object](
+	ctx context.Context,
+	c client.Client,
+	o *CreateOrUseOptions,
+	best, template T,
+	mutateFunc func(obj T) error,
+) (T, controllerutil.OperationResult, error) {
+	var zero T
+	if o.PatchStrategy == PatchStrategyApply {
+		gvk, err := c.GroupVersionKindFor(best)
+		if err != nil {
+			return zero, controllerutil.OperationResultNone, fmt.Errorf("error getting group version kind: %w", err)
+		}
+		obj := template.DeepCopyObject().(T)
+		resetObject(obj, gvk)
+		obj.SetNamespace(best.GetNamespace())
+		obj.SetName(best.GetName())
+		if mutateFunc != nil {
+			if err := mutateFunc(obj); err != nil {
+				return zero, controllerutil.OperationResultNone, err
+			}
+		}
+
+		if err := applyObject(ctx, c, obj, "", o.applyPatchOptions()); err != nil {
+			return zero, controllerutil.OperationResultNone, err
+		}
+		if obj.GetResourceVersion() == best.GetResourceVersion() {
+			return obj, controllerutil.OperationResultNone, nil
+		}
+		return obj, controllerutil.OperationResultUpdated, nil
+	}
+
+	obj := best.DeepCopyObject().(T)
+	base := best.DeepCopyObject().(T)
+	if mutateFunc != nil {
+		if err := mutateFunc(obj); err != nil {
+			return zero, controllerutil.OperationResultNone, err
+		}
+	}
+	if equality.Semantic.DeepEqual(base, obj) {
+		return obj, controllerutil.OperationResultNone, nil
+	}
+
+	if err := c.Patch(ctx, obj, client.MergeFrom(base)); err != nil {
+		return zero, controllerutil.OperationResultNone, err
+	}
+	return obj, controllerutil.OperationResultUpdated, nil
+}
+
+func createFromTemplate[T client.Object](
+	ctx context.Context,
+	c client.Client,
+	o *CreateOrUseOptions,
+	template T,
+	mutateFunc func(obj T) error,
+) (T, controllerutil.OperationResult, error) {
+	var zero T
+	obj := template.DeepCopyObject().(T)
+	if mutateFunc != nil {
+		if err := mutateFunc(obj); err != nil {
+			return zero, controllerutil.OperationResultNone, err
+		}
+	}
+
+	if o.PatchStrategy == PatchStrategyApply {
+		if err := applyObject(ctx, c, obj, "", o.applyPatchOptions()); err != nil {
+			return zero, controllerutil.OperationResultNone, err
+		}
+		return obj, controllerutil.OperationResultCreated, nil
+	}
+
+	if err := c.Create(ctx, obj); err != nil {
+		return zero, controllerutil.OperationResultNone, err
+	}
+	return obj, controllerutil.OperationResultCreated, nil
+}
+
+func (o *CreateOrUseOptions) applyPatchOptions() *client.PatchOptions {
+	patchOpts := &client.PatchOptions{FieldManager: o.FieldManager}
+	if o.Force {
+		client.ForceOwnership.ApplyToPatch(patchOpts)
+	}
+	return patchOpts
+}
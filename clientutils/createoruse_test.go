@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var _ = Describe("CreateOrUseAndPatchOf", func() {
+	var (
+		ctx      context.Context
+		c        client.Client
+		template *corev1.ConfigMap
+		objects  []*corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		template = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, GenerateName: "cm-"}}
+		objects = []*corev1.ConfigMap{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "other", CreationTimestamp: metav1.Unix(100, 0)}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "match-new", CreationTimestamp: metav1.Unix(300, 0)}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "match-old", CreationTimestamp: metav1.Unix(200, 0)}},
+		}
+		b := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+		for _, obj := range objects {
+			b = b.WithObjects(obj.DeepCopy())
+		}
+		c = b.Build()
+		for _, obj := range objects {
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+		}
+	})
+
+	match := func(cm *corev1.ConfigMap) (bool, error) {
+		return strings.HasPrefix(cm.Name, "match-"), nil
+	}
+	setData := func(cm *corev1.ConfigMap) error {
+		cm.Data = map[string]string{"foo": "bar"}
+		return nil
+	}
+
+	It("should use the preferred matching object and patch it", func() {
+		obj, res, surplus, err := CreateOrUseAndPatchOf(ctx, c, objects, template, match, IsOlder, setData)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(controllerutil.OperationResultUpdated))
+		Expect(obj.Name).To(Equal("match-old"))
+		Expect(obj.Data).To(HaveKeyWithValue("foo", "bar"))
+		Expect(surplus).To(Equal([]*corev1.ConfigMap{objects[1]}))
+		Expect(objects[2].Data).To(BeEmpty(), "input objects should not be modified")
+
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(obj), cm)).To(Succeed())
+		Expect(cm.Data).To(HaveKeyWithValue("foo", "bar"))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(objects[1]), cm)).To(Succeed())
+	})
+
+	It("should not patch the object if the mutation does not change it", func() {
+		obj, res, _, err := CreateOrUseAndPatchOf(ctx, c, objects, template, match, IsOlder, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(controllerutil.OperationResultNone))
+		Expect(obj).To(Equal(objects[2]))
+	})
+
+	It("should create a new object from the template if none matches", func() {
+		obj, res, surplus, err := CreateOrUseAndPatchOf(ctx, c, objects, template, func(*corev1.ConfigMap) (bool, error) {
+			return false, nil
+		}, IsOlder, setData)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(controllerutil.OperationResultCreated))
+		Expect(surplus).To(BeEmpty())
+		Expect(obj.Name).To(HavePrefix("cm-"))
+		Expect(obj.Data).To(HaveKeyWithValue("foo", "bar"))
+		Expect(template.Name).To(BeEmpty(), "template should not be modified")
+	})
+
+	It("should delete the surplus objects", func() {
+		_, _, surplus, err := CreateOrUseAndPatchOf(ctx, c, objects, template, match, IsOlder, setData, DeleteSurplus{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(surplus).To(Equal([]*corev1.ConfigMap{objects[1]}))
+
+		Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(objects[1]), &corev1.ConfigMap{}))).To(BeTrue())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(objects[0]), &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should apply the desired state server-side", func() {
+		template.Labels = map[string]string{"from": "template"}
+		obj, res, _, err := CreateOrUseAndPatchOf(ctx, c, objects, template, match, IsOlder, func(cm *corev1.ConfigMap) error {
+			Expect(cm.Name).To(Equal("match-old"))
+			Expect(cm.GenerateName).To(BeEmpty())
+			Expect(cm.Labels).To(BeEmpty())
+			Expect(cm.ResourceVersion).To(BeEmpty())
+			return setData(cm)
+		}, UseServerSideApply{FieldManager: "my-manager"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(controllerutil.OperationResultUpdated))
+		Expect(obj.Data).To(HaveKeyWithValue("foo", "bar"))
+
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(obj), cm)).To(Succeed())
+		Expect(cm.Data).To(HaveKeyWithValue("foo", "bar"))
+	})
+})
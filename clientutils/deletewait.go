@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StuckObject is an object that was not deleted in time.
+type StuckObject struct {
+	// Ref references the object. Its GroupKind is empty if it could not be determined.
+	Ref ObjectRef
+	// DeletionTimestamp is the deletion timestamp of the object, if any.
+	DeletionTimestamp *metav1.Time
+	// Finalizers are the finalizers still blocking the deletion of the object.
+	Finalizers []string
+}
+
+func (o StuckObject) String() string {
+	var sb strings.Builder
+	if !o.Ref.GroupKind.Empty() {
+		sb.WriteString(o.Ref.GroupKind.String())
+		sb.WriteString(" ")
+	}
+	sb.WriteString(o.Ref.Key.String())
+	if len(o.Finalizers) > 0 {
+		sb.WriteString(" (finalizers: ")
+		sb.WriteString(strings.Join(o.Finalizers, ", "))
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// DeletionStuckError is returned by DeleteIfExistsAndWait and DeleteMultipleIfExistAndWait if
+// any object was not gone in time.
+type DeletionStuckError struct {
+	// Stuck are the objects that were not gone in time.
+	Stuck []StuckObject
+	// Err is the original error, usually wrapping context.DeadlineExceeded or context.Canceled.
+	Err error
+}
+
+// Error implements error.
+func (e *DeletionStuckError) Error() string {
+	items := make([]string, 0, len(e.Stuck))
+	for _, obj := range e.Stuck {
+		items = append(items, obj.String())
+	}
+	return fmt.Sprintf("objects not deleted in time: %s: %v", strings.Join(items, ", "), e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *DeletionStuckError) Unwrap() error {
+	return e.Err
+}
+
+// splitWaitOptions separates the WaitOption values from the given client options.
+func splitWaitOptions[O any](opts []O) (*WaitOptions, []O) {
+	o := &WaitOptions{}
+	var rest []O
+	for _, opt := range opts {
+		if wOpt, ok := any(opt).(WaitOption); ok {
+			wOpt.ApplyToWait(o)
+			continue
+		}
+		rest = append(rest, opt)
+	}
+	return o, rest
+}
+
+// DeleteIfExistsAndWait deletes the given object, if it exists, and waits until it is gone.
+// It returns whether the object existed before issuing the delete request.
+//
+// Any WaitOption (e.g. WaitTimeout) given in opts controls waiting, the remaining options (e.g.
+// client.PropagationPolicy) are passed to the client. If the object is not gone in time, a *DeletionStuckError
+// reporting the finalizers still blocking the object is returned.
+func DeleteIfExistsAndWait(ctx context.Context, c client.Client, obj client.Object, opts ...client.DeleteOption) (existed bool, err error) {
+	waitOpts, opts := splitWaitOptions(opts)
+	existed, err = DeleteIfExists(ctx, c, obj, opts...)
+	if err != nil || !existed {
+		return existed, err
+	}
+
+	if err := WaitFor(ctx, c, obj, Deleted, waitOpts); err != nil {
+		return true, newDeletionStuckError(c, err)
+	}
+	return true, nil
+}
+
+// DeleteMultipleIfExistAndWait deletes the given objects, if they exist, and waits until all of them are gone.
+// It returns any object that existed before issuing the delete request.
+//
+// Any WaitOption (e.g. WaitTimeout) given in opts controls waiting, any MultipleOption controls deleting as in
+// DeleteMultipleIfExist. The remaining options (e.g. client.PropagationPolicy) are passed to the client.
+// If any object is not gone in time, a *DeletionStuckError reporting all stuck objects and the finalizers still
+// blocking them is returned.
+func DeleteMultipleIfExistAndWait(ctx context.Context, c client.Client, objs []client.Object, opts ...client.DeleteOption) (existed []client.Object, err error) {
+	waitOpts, opts := splitWaitOptions(opts)
+	existed, err = DeleteMultipleIfExist(ctx, c, objs, opts...)
+	if err != nil {
+		return existed, err
+	}
+
+	if err := WaitForMultiple(ctx, c, existed, Deleted, waitOpts); err != nil {
+		return existed, newDeletionStuckError(c, err)
+	}
+	return existed, nil
+}
+
+// newDeletionStuckError converts the error of waiting for deletion into a *DeletionStuckError.
+// If err is not caused by waiting too long for any object, it is returned as-is.
+func newDeletionStuckError(c clientMeta, err error) error {
+	var waitErrs []*WaitError
+	var multiErr *MultipleError
+	if errors.As(err, &multiErr) {
+		for _, objErr := range multiErr.Failed {
+			var waitErr *WaitError
+			if !errors.As(objErr, &waitErr) {
+				return err
+			}
+			waitErrs = append(waitErrs, waitErr)
+		}
+	} else {
+		var waitErr *WaitError
+		if !errors.As(err, &waitErr) {
+			return err
+		}
+		waitErrs = append(waitErrs, waitErr)
+	}
+
+	res := &DeletionStuckError{Err: err}
+	for _, waitErr := range waitErrs {
+		obj := StuckObject{Ref: ObjectRef{Key: waitErr.Key}}
+		if last := waitErr.Last; last != nil {
+			if gvk, err := c.GroupVersionKindFor(last); err == nil {
+				obj.Ref.GroupKind = gvk.GroupKind()
+			}
+			if waitErr.Exists {
+				obj.DeletionTimestamp = last.GetDeletionTimestamp()
+				obj.Finalizers = last.GetFinalizers()
+			}
+		}
+		res.Stuck = append(res.Stuck, obj)
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("DeleteAndWait", func() {
+	const finalizer = "example.org/finalizer"
+
+	var (
+		ctx         context.Context
+		c           client.Client
+		deleteOpts  []*client.DeleteOptions
+		free, stuck *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		deleteOpts = nil
+		free = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "free"}}
+		stuck = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace:  corev1.NamespaceDefault,
+			Name:       "stuck",
+			Finalizers: []string{finalizer},
+		}}
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(free.DeepCopy(), stuck.DeepCopy()).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					deleteOpts = append(deleteOpts, (&client.DeleteOptions{}).ApplyOptions(opts))
+					return c.Delete(ctx, obj, opts...)
+				},
+			}).
+			Build()
+	})
+
+	Describe("DeleteIfExistsAndWait", func() {
+		It("should delete the object and wait until it is gone", func() {
+			existed, err := DeleteIfExistsAndWait(ctx, c, free, client.PropagationPolicy(metav1.DeletePropagationForeground), WaitTimeout(5*time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(existed).To(BeTrue())
+			Expect(deleteOpts).To(ConsistOf(HaveField("PropagationPolicy", HaveValue(Equal(metav1.DeletePropagationForeground)))))
+		})
+
+		It("should report whether the object existed", func() {
+			existed, err := DeleteIfExistsAndWait(ctx, c, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "other"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(existed).To(BeFalse())
+		})
+
+		It("should report the finalizers blocking the object", func() {
+			_, err := DeleteIfExistsAndWait(ctx, c, stuck, WaitTimeout(50*time.Millisecond), WaitNoWatch{}, WaitInterval(10*time.Millisecond))
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+			var stuckErr *DeletionStuckError
+			Expect(errors.As(err, &stuckErr)).To(BeTrue())
+			Expect(stuckErr.Stuck).To(HaveLen(1))
+			Expect(stuckErr.Stuck[0].Ref).To(Equal(ObjectRef{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Key: client.ObjectKeyFromObject(stuck)}))
+			Expect(stuckErr.Stuck[0].Finalizers).To(Equal([]string{finalizer}))
+			Expect(stuckErr.Stuck[0].DeletionTimestamp).NotTo(BeNil())
+			Expect(err).To(MatchError(ContainSubstring("default/stuck (finalizers: example.org/finalizer)")))
+		})
+	})
+
+	Describe("DeleteMultipleIfExistAndWait", func() {
+		It("should delete all objects and report the stuck ones", func() {
+			existed, err := DeleteMultipleIfExistAndWait(ctx, c, []client.Object{free, stuck}, WaitTimeout(100*time.Millisecond), MaxConcurrency(2))
+			Expect(existed).To(Equal([]client.Object{free, stuck}))
+
+			var stuckErr *DeletionStuckError
+			Expect(errors.As(err, &stuckErr)).To(BeTrue())
+			Expect(stuckErr.Stuck).To(ConsistOf(HaveField("Ref.Key", client.ObjectKeyFromObject(stuck))))
+			Expect(deleteOpts).To(HaveLen(2))
+		})
+
+		It("should succeed once all objects are gone", func() {
+			existed, err := DeleteMultipleIfExistAndWait(ctx, c, []client.Object{free}, WaitTimeout(5*time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(existed).To(Equal([]client.Object{free}))
+		})
+	})
+})
@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ironcore-dev/controller-utils/unstructuredutils"
+	"github.com/pmezard/go-difflib/difflib"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// DiffAction is the action that would be taken on an object.
+type DiffAction string
+
+const (
+	// DiffActionCreate means the object does not exist and would be created.
+	DiffActionCreate DiffAction = "Create"
+	// DiffActionUnchanged means the object exists and would not be changed.
+	DiffActionUnchanged DiffAction = "Unchanged"
+	// DiffActionChange means the object exists and would be changed.
+	DiffActionChange DiffAction = "Change"
+	// DiffActionPrune means the object exists and would be deleted by pruning.
+	DiffActionPrune DiffAction = "Prune"
+)
+
+// DiffResult is the result of diffing an object against its live state.
+type DiffResult struct {
+	// Ref references the object.
+	Ref ObjectRef
+	// Action is the action that would be taken on the object.
+	Action DiffAction
+	// Diff is a unified diff of the YAML of the live and the resulting object.
+	// It is empty if the object would not be changed.
+	Diff string
+	// Live is the live object. It is nil if the object does not exist.
+	Live *unstructured.Unstructured
+	// Merged is the object as it would be after patching it. It is nil if the object would be pruned.
+	Merged *unstructured.Unstructured
+}
+
+// DiffPrune makes DiffMultiple report the members of ApplySet that would be pruned.
+// It implements client.PatchOption as a no-op and is filtered out before calling the client.
+type DiffPrune struct {
+	ApplySet *ApplySet
+}
+
+// ApplyToPatch implements client.PatchOption. It is a no-op, DiffPrune is only respected by DiffMultiple.
+func (DiffPrune) ApplyToPatch(*client.PatchOptions) {}
+
+// DiffMultipleFromFile reads the given file as unstructured objects and diffs patching them with the given
+// PatchProvider using DiffMultiple.
+func DiffMultipleFromFile(
+	ctx context.Context,
+	c client.Client,
+	filename string,
+	patchProvider PatchProvider,
+	opts ...client.PatchOption,
+) ([]DiffResult, error) {
+	objs, err := unstructuredutils.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	reqs := PatchRequestsFromObjectsAndProvider(unstructuredutils.UnstructuredSliceToObjectSliceNoCopy(objs), patchProvider)
+	return DiffMultiple(ctx, c, reqs, opts...)
+}
+
+// DiffMultiple determines what would change when running PatchMultiple with the given requests.
+//
+// For each request, the live object is fetched and the patch is run in server dry-run mode. Objects that do not
+// exist are created in server dry-run mode instead. Neither the requests nor the live objects are modified.
+// Server-managed fields like uid, managed fields, resource version, generation and creation timestamp are not part of
+// the reported diff. If DiffPrune is passed, the members of the ApplySet that would be pruned are reported as well.
+//
+// The remaining options are passed to the client, client.DryRunAll is always added.
+func DiffMultiple(ctx context.Context, c client.Client, reqs []PatchRequest, opts ...client.PatchOption) ([]DiffResult, error) {
+	o, opts := splitMultipleOptions(opts)
+	var applySet *ApplySet
+	patchOpts := make([]client.PatchOption, 0, len(opts)+1)
+	for _, opt := range opts {
+		if p, ok := opt.(DiffPrune); ok {
+			applySet = p.ApplySet
+			continue
+		}
+		patchOpts = append(patchOpts, opt)
+	}
+	patchOpts = append(patchOpts, client.DryRunAll)
+	createOpts := &client.CreateOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: (&client.PatchOptions{}).ApplyOptions(patchOpts).FieldManager,
+	}
+
+	objs := ObjectsFromPatchRequests(reqs)
+	res := make([]DiffResult, len(reqs))
+	if err := o.run(ctx, c, batch{
+		verb: VerbPatch,
+		objs: objs,
+		do: func(ctx context.Context, i int) error {
+			var err error
+			res[i], err = diffObject(ctx, c, reqs[i], patchOpts, createOpts)
+			return err
+		},
+		wrap: func(i int, err error) error {
+			return fmt.Errorf("error diffing object %s: %w", client.ObjectKeyFromObject(objs[i]), err)
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	if applySet == nil {
+		return res, nil
+	}
+
+	pruned, err := applySet.Prunable(ctx, objs)
+	if err != nil {
+		return nil, fmt.Errorf("error listing prunable objects: %w", err)
+	}
+	for _, ref := range pruned {
+		r, err := diffPruned(ctx, c, ref)
+		if err != nil {
+			return nil, fmt.Errorf("error diffing pruned object %s %s: %w", ref.GroupKind, ref.Key, err)
+		}
+		if r != nil {
+			res = append(res, *r)
+		}
+	}
+	return res, nil
+}
+
+func diffObject(ctx context.Context, c client.Client, req PatchRequest, patchOpts []client.PatchOption, createOpts *client.CreateOptions) (DiffResult, error) {
+	gvk, err := c.GroupVersionKindFor(req.Object)
+	if err != nil {
+		return DiffResult{}, fmt.Errorf("error getting group version kind: %w", err)
+	}
+	res := DiffResult{Ref: ObjectRef{GroupKind: gvk.GroupKind(), Key: client.ObjectKeyFromObject(req.Object)}}
+
+	// Get into an empty object, since decoding into a prefilled one keeps the fields the live object omits.
+	live, err := newObjectForGVK(c.Scheme(), gvk)
+	if err != nil {
+		return DiffResult{}, err
+	}
+	if err := c.Get(ctx, res.Ref.Key, live); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return DiffResult{}, fmt.Errorf("error getting live object: %w", err)
+		}
+		live = nil
+	}
+
+	merged := req.Object.DeepCopyObject().(client.Object)
+	if live == nil {
+		res.Action = DiffActionCreate
+		err = c.Create(ctx, merged, createOpts)
+	} else {
+		err = c.Patch(ctx, merged, req.Patch, patchOpts...)
+	}
+	if err != nil {
+		return DiffResult{}, fmt.Errorf("error running dry run: %w", err)
+	}
+
+	if live != nil {
+		if res.Live, err = normalizeForDiff(live, gvk.GroupVersion().String(), gvk.Kind); err != nil {
+			return DiffResult{}, err
+		}
+	}
+	if res.Merged, err = normalizeForDiff(merged, gvk.GroupVersion().String(), gvk.Kind); err != nil {
+		return DiffResult{}, err
+	}
+
+	if res.Diff, err = unifiedYAMLDiff(res.Ref, res.Live, res.Merged); err != nil {
+		return DiffResult{}, err
+	}
+	if res.Action == "" {
+		if res.Diff == "" {
+			res.Action = DiffActionUnchanged
+		} else {
+			res.Action = DiffActionChange
+		}
+	}
+	return res, nil
+}
+
+// diffPruned diffs the pruning of the given object. It returns nil if the object does not exist anymore.
+func diffPruned(ctx context.Context, c client.Client, ref ObjectRef) (*DiffResult, error) {
+	mapping, err := c.RESTMapper().RESTMapping(ref.GroupKind)
+	if err != nil {
+		return nil, fmt.Errorf("error getting rest mapping: %w", err)
+	}
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(mapping.GroupVersionKind)
+	if err := c.Get(ctx, ref.Key, live); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting live object: %w", err)
+	}
+
+	res := &DiffResult{Ref: ref, Action: DiffActionPrune}
+	if res.Live, err = normalizeForDiff(live, mapping.GroupVersionKind.GroupVersion().String(), mapping.GroupVersionKind.Kind); err != nil {
+		return nil, err
+	}
+	if res.Diff, err = unifiedYAMLDiff(ref, res.Live, nil); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// normalizeForDiff converts the given object to an unstructured.Unstructured and strips server-managed fields.
+func normalizeForDiff(obj client.Object, apiVersion, kind string) (*unstructured.Unstructured, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error converting object to unstructured: %w", err)
+	}
+
+	u := &unstructured.Unstructured{Object: data}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	removeServerMetadataFields(u.Object)
+	return u, nil
+}
+
+// serverMetadataFields are the metadata fields set and managed by the server.
+var serverMetadataFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"selfLink",
+}
+
+// removeServerMetadataFields removes all serverMetadataFields from the given unstructured content.
+func removeServerMetadataFields(content map[string]any) {
+	for _, field := range serverMetadataFields {
+		unstructured.RemoveNestedField(content, "metadata", field)
+	}
+}
+
+func unifiedYAMLDiff(ref ObjectRef, from, to *unstructured.Unstructured) (string, error) {
+	fromYAML, err := yamlForDiff(from)
+	if err != nil {
+		return "", err
+	}
+	toYAML, err := yamlForDiff(to)
+	if err != nil {
+		return "", err
+	}
+	if fromYAML == toYAML {
+		return "", nil
+	}
+
+	name := ref.GroupKind.String() + " " + ref.Key.String()
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fromYAML),
+		B:        difflib.SplitLines(toYAML),
+		FromFile: "live/" + name,
+		ToFile:   "merged/" + name,
+		Context:  3,
+	})
+}
+
+func yamlForDiff(u *unstructured.Unstructured) (string, error) {
+	if u == nil {
+		return "", nil
+	}
+	data, err := yaml.Marshal(u.Object)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling object to yaml: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	"github.com/ironcore-dev/controller-utils/testdata"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Diff", func() {
+	const objectsPath = "../testdata/bases/objects.yaml"
+
+	var (
+		ctx  context.Context
+		c    client.Client
+		cmGK schema.GroupKind
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)).
+			WithInterceptorFuncs(interceptor.Funcs{
+				// Like the server, assign a uid to created objects, also when creating in dry-run mode.
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					obj.SetUID(uuid.NewUUID())
+					return c.Create(ctx, obj, opts...)
+				},
+			}).
+			Build()
+		cmGK = schema.GroupKind{Kind: "ConfigMap"}
+	})
+
+	configMap := func(name, value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: name},
+			Data:       map[string]string{"foo": value},
+		}
+	}
+	ref := func(gk schema.GroupKind, name string) ObjectRef {
+		return ObjectRef{GroupKind: gk, Key: client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: name}}
+	}
+
+	Describe("DiffMultiple", func() {
+		It("should report created, unchanged and changed objects without modifying them", func() {
+			Expect(c.Create(ctx, configMap("unchanged", "bar"))).To(Succeed())
+			Expect(c.Create(ctx, configMap("changed", "bar"))).To(Succeed())
+
+			objs := []client.Object{configMap("unchanged", "bar"), configMap("changed", "baz"), configMap("new", "bar")}
+			res, err := DiffMultiple(ctx, c, PatchRequestsFromObjectsAndProvider(objs, ApplyAll), client.FieldOwner("my-manager"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(3))
+
+			Expect(res[0]).To(HaveField("Ref", ref(cmGK, "unchanged")))
+			Expect(res[0]).To(HaveField("Action", DiffActionUnchanged))
+			Expect(res[0]).To(HaveField("Diff", BeEmpty()))
+
+			Expect(res[1]).To(HaveField("Ref", ref(cmGK, "changed")))
+			Expect(res[1]).To(HaveField("Action", DiffActionChange))
+			Expect(res[1].Diff).To(ContainSubstring("--- live/ConfigMap default/changed"))
+			Expect(res[1].Diff).To(ContainSubstring("-  foo: bar"))
+			Expect(res[1].Diff).To(ContainSubstring("+  foo: baz"))
+			Expect(res[1].Diff).NotTo(ContainSubstring("resourceVersion"))
+			Expect(res[1].Live.GetKind()).To(Equal("ConfigMap"))
+
+			Expect(res[2]).To(HaveField("Ref", ref(cmGK, "new")))
+			Expect(res[2]).To(HaveField("Action", DiffActionCreate))
+			Expect(res[2].Live).To(BeNil())
+			Expect(res[2].Diff).To(ContainSubstring("+  foo: bar"))
+			Expect(res[2].Diff).NotTo(ContainSubstring("uid"))
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "changed"}, cm)).To(Succeed())
+			Expect(cm.Data).To(HaveKeyWithValue("foo", "bar"))
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "new"}, cm)).NotTo(Succeed())
+		})
+
+		It("should report fields that are missing in the live object as changed", func() {
+			Expect(c.Create(ctx, configMap("cm", "bar"))).To(Succeed())
+			// Like the server, decode the live object into the given object without zeroing it first.
+			c = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					live := obj.DeepCopyObject().(client.Object)
+					if err := c.Get(ctx, key, live, opts...); err != nil {
+						return err
+					}
+					data, err := json.Marshal(live)
+					if err != nil {
+						return err
+					}
+					return json.Unmarshal(data, obj)
+				},
+			})
+
+			obj := configMap("cm", "bar")
+			obj.Labels = map[string]string{"new": "label"}
+			res, err := DiffMultiple(ctx, c, PatchRequestsFromObjectsAndProvider([]client.Object{obj}, ApplyAll), client.FieldOwner("my-manager"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(1))
+			Expect(res[0]).To(HaveField("Action", DiffActionChange))
+			Expect(res[0].Live.GetLabels()).To(BeEmpty())
+			Expect(res[0].Diff).To(ContainSubstring("+    new: label"))
+		})
+
+		It("should report objects that would be pruned", func() {
+			parent := configMap("parent", "")
+			Expect(c.Create(ctx, parent)).To(Succeed())
+			s, err := NewApplySet(c, parent, "my-manager")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = s.Apply(ctx, []client.Object{configMap("kept", "bar"), configMap("removed", "bar")})
+			Expect(err).NotTo(HaveOccurred())
+
+			objs := []client.Object{configMap("kept", "bar")}
+			res, err := DiffMultiple(ctx, c, PatchRequestsFromObjectsAndProvider(objs, ApplyAll), DiffPrune{ApplySet: s}, client.FieldOwner("my-manager"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(2))
+			Expect(res[1]).To(HaveField("Ref", ref(cmGK, "removed")))
+			Expect(res[1]).To(HaveField("Action", DiffActionPrune))
+			Expect(res[1].Merged).To(BeNil())
+			Expect(res[1].Diff).To(ContainSubstring("-  foo: bar"))
+
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "removed"}, &corev1.ConfigMap{})).To(Succeed())
+		})
+	})
+
+	Describe("DiffMultipleFromFile", func() {
+		It("should error if the file does not exist", func() {
+			_, err := DiffMultipleFromFile(ctx, c, "should-not-exist", ApplyAll)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should diff the objects from file", func() {
+			Expect(c.Create(ctx, testdata.UnstructuredConfigMap())).To(Succeed())
+
+			res, err := DiffMultipleFromFile(ctx, c, objectsPath, ApplyAll, client.FieldOwner("my-manager"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(2))
+			Expect(res[0]).To(HaveField("Action", DiffActionCreate))
+			Expect(res[1]).To(HaveField("Ref", ObjectRef{GroupKind: cmGK, Key: client.ObjectKeyFromObject(testdata.UnstructuredConfigMap())}))
+			Expect(res[1]).To(HaveField("Action", DiffActionUnchanged))
+		})
+	})
+})
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type dryRunClient struct {
+	client.Client
+}
+
+// DryRunClient returns a client.Client that runs all write operations of the given client with
+// client.DryRunAll, so they are validated and admitted by the API server without being persisted.
+// Read operations are run unmodified.
+func DryRunClient(c client.Client) client.Client {
+	return dryRunClient{c}
+}
+
+// withDryRun returns a copy of opts with dryRun appended, so it takes precedence over any other dry run option.
+func withDryRun[O any](opts []O, dryRun O) []O {
+	return append(slices.Clip(opts), dryRun)
+}
+
+func (c dryRunClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.Client.Create(ctx, obj, withDryRun[client.CreateOption](opts, client.DryRunAll)...)
+}
+
+func (c dryRunClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return c.Client.Update(ctx, obj, withDryRun[client.UpdateOption](opts, client.DryRunAll)...)
+}
+
+func (c dryRunClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.Client.Patch(ctx, obj, patch, withDryRun[client.PatchOption](opts, client.DryRunAll)...)
+}
+
+func (c dryRunClient) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	return c.Client.Apply(ctx, obj, withDryRun[client.ApplyOption](opts, client.DryRunAll)...)
+}
+
+func (c dryRunClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return c.Client.Delete(ctx, obj, withDryRun[client.DeleteOption](opts, client.DryRunAll)...)
+}
+
+func (c dryRunClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return c.Client.DeleteAllOf(ctx, obj, withDryRun[client.DeleteAllOfOption](opts, client.DryRunAll)...)
+}
+
+func (c dryRunClient) Status() client.SubResourceWriter {
+	return dryRunSubResourceWriter{c.Client.Status()}
+}
+
+func (c dryRunClient) SubResource(subResource string) client.SubResourceClient {
+	sc := c.Client.SubResource(subResource)
+	return dryRunSubResourceClient{sc, dryRunSubResourceWriter{sc}}
+}
+
+type dryRunSubResourceWriter struct {
+	client.SubResourceWriter
+}
+
+func (w dryRunSubResourceWriter) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	return w.SubResourceWriter.Create(ctx, obj, subResource, withDryRun[client.SubResourceCreateOption](opts, client.DryRunAll)...)
+}
+
+func (w dryRunSubResourceWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return w.SubResourceWriter.Update(ctx, obj, withDryRun[client.SubResourceUpdateOption](opts, client.DryRunAll)...)
+}
+
+func (w dryRunSubResourceWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	return w.SubResourceWriter.Patch(ctx, obj, patch, withDryRun[client.SubResourcePatchOption](opts, client.DryRunAll)...)
+}
+
+func (w dryRunSubResourceWriter) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
+	return w.SubResourceWriter.Apply(ctx, obj, withDryRun[client.SubResourceApplyOption](opts, client.DryRunAll)...)
+}
+
+type dryRunSubResourceClient struct {
+	reader client.SubResourceReader
+	dryRunSubResourceWriter
+}
+
+func (c dryRunSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	return c.reader.Get(ctx, obj, subResource, opts...)
+}
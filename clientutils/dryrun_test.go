@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("DryRunClient", func() {
+	var (
+		ctx   context.Context
+		fakeC client.Client
+		c     client.Client
+		cm    *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-cm"}}
+		fakeC = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm.DeepCopy()).Build()
+		c = DryRunClient(fakeC)
+	})
+
+	It("should not persist any writes", func() {
+		newCM := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "new-cm"}}
+		Expect(c.Create(ctx, newCM)).To(Succeed())
+		Expect(apierrors.IsNotFound(fakeC.Get(ctx, client.ObjectKeyFromObject(newCM), &corev1.ConfigMap{}))).To(BeTrue())
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		base := cm.DeepCopy()
+		cm.Data = map[string]string{"foo": "bar"}
+		Expect(c.Patch(ctx, cm, client.MergeFrom(base))).To(Succeed())
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		Expect(c.Delete(ctx, cm)).To(Succeed())
+		Expect(c.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace(corev1.NamespaceDefault))).To(Succeed())
+
+		actual := &corev1.ConfigMap{}
+		Expect(fakeC.Get(ctx, client.ObjectKeyFromObject(cm), actual)).To(Succeed())
+		Expect(actual.Data).To(BeEmpty())
+	})
+
+	It("should be stackable with ReaderClient", func() {
+		reader := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+		c = DryRunClient(ReaderClient(reader, fakeC))
+
+		Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}))).To(BeTrue())
+		Expect(c.Delete(ctx, cm)).To(Succeed())
+		Expect(fakeC.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})).To(Succeed())
+	})
+})
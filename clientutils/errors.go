@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"errors"
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Verb is an operation run on an object.
+type Verb string
+
+const (
+	// VerbGet gets an object.
+	VerbGet Verb = "get"
+	// VerbList lists objects.
+	VerbList Verb = "list"
+	// VerbCreate creates an object.
+	VerbCreate Verb = "create"
+	// VerbUpdate updates an object.
+	VerbUpdate Verb = "update"
+	// VerbPatch patches an object.
+	VerbPatch Verb = "patch"
+	// VerbApply applies an object server-side.
+	VerbApply Verb = "apply"
+	// VerbDelete deletes an object.
+	VerbDelete Verb = "delete"
+	// VerbDeleteAllOf deletes all objects matching the given options.
+	VerbDeleteAllOf Verb = "deleteallof"
+	// VerbWait waits for an object to satisfy a predicate.
+	VerbWait Verb = "wait"
+)
+
+// ObjectError is the error of processing an individual object of a batch operation.
+type ObjectError struct {
+	// Index is the index of the object in the input of the batch operation.
+	Index int
+	// Ref references the object. Its GroupKind is empty if it could not be determined.
+	Ref ObjectRef
+	// Verb is the operation that failed.
+	Verb Verb
+	// Err is the cause of the failure.
+	Err error
+}
+
+// Error implements error.
+func (e *ObjectError) Error() string {
+	if e.Ref.GroupKind.Empty() {
+		return fmt.Sprintf("[object %d]: error running %s on %s: %v", e.Index, e.Verb, e.Ref.Key, e.Err)
+	}
+	return fmt.Sprintf("[object %d]: error running %s on %s %s: %v", e.Index, e.Verb, e.Ref.GroupKind, e.Ref.Key, e.Err)
+}
+
+// Unwrap returns the cause of the failure.
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// MultipleError is returned by batch operations run with ContinueOnError if any object could not be processed.
+// It implements utilerrors.Aggregate and supports errors.Is / errors.As on the individual ObjectError.
+type MultipleError struct {
+	// Failed are the errors of all objects that could not be processed, ordered by their index.
+	Failed []*ObjectError
+	// Succeeded are the indices of all objects that were processed successfully, in ascending order.
+	Succeeded []int
+}
+
+// Error implements error.
+func (e *MultipleError) Error() string {
+	return utilerrors.NewAggregate(e.Errors()).Error()
+}
+
+// Errors implements utilerrors.Aggregate.
+func (e *MultipleError) Errors() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Is implements utilerrors.Aggregate. It reports whether any of the individual errors matches target.
+func (e *MultipleError) Is(target error) bool {
+	for _, err := range e.Failed {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the individual errors.
+func (e *MultipleError) Unwrap() []error {
+	return e.Errors()
+}
+
+// newMultipleError creates a new *MultipleError from the per-object errors of a batch.
+// It returns nil if all objects succeeded.
+func newMultipleError(c clientMeta, b *batch, errs []error) error {
+	res := &MultipleError{}
+	for i, err := range errs {
+		if err == nil {
+			res.Succeeded = append(res.Succeeded, i)
+			continue
+		}
+
+		ref := ObjectRef{Key: b.objectKey(i)}
+		if gvk, err := c.GroupVersionKindFor(b.objs[i]); err == nil {
+			ref.GroupKind = gvk.GroupKind()
+		}
+		res.Failed = append(res.Failed, &ObjectError{
+			Index: i,
+			Ref:   ref,
+			Verb:  b.verb,
+			Err:   err,
+		})
+	}
+	if len(res.Failed) == 0 {
+		return nil
+	}
+	return res
+}
@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FaultRule describes a fault a FaultClient injects into the operations selected by its filters.
+//
+// A rule first skips the operations selected before the After-th one. It then injects its fault into
+// the selected operations with the given Probability until it injected Times faults.
+type FaultRule struct {
+	// Filters select the operations the rule applies to. If empty, the rule applies to all operations.
+	Filters []OperationFilter
+	// After is the number of selected operations to skip before injecting faults.
+	After int
+	// Times is the maximum number of faults to inject. If zero, the number of faults is unlimited.
+	Times int
+	// Probability is the probability to inject the fault into a selected operation. If zero, the fault is
+	// always injected.
+	Probability float64
+
+	// Latency delays the operation. If the context is done while waiting, its error is returned.
+	Latency time.Duration
+	// Err is returned instead of running the operation.
+	Err error
+	// Drop skips write operations, reporting success without modifying the passed object.
+	// It has no effect on read operations.
+	Drop bool
+}
+
+type faultRule struct {
+	FaultRule
+	selected int
+	injected int
+}
+
+// FaultClient is a client.Client that injects faults into operations according to FaultRules.
+// It is safe for concurrent use.
+//
+// Faults are injected before running an operation, so the Operation passed to the filters of get and list
+// operations carries no Object and e.g. OperationLabelSelector does not select reads. For other operations,
+// the Object is a copy of the passed object.
+type FaultClient struct {
+	client.Client
+
+	mu       sync.Mutex
+	rules    []*faultRule
+	injected int
+}
+
+// NewFaultClient creates a new FaultClient that runs all operations using the given client
+// after injecting the faults of the given rules.
+func NewFaultClient(c client.Client, rules ...FaultRule) *FaultClient {
+	f := &FaultClient{Client: c}
+	for _, rule := range rules {
+		f.AddRule(rule)
+	}
+	return f
+}
+
+// AddRule adds the given rule. Rules are evaluated in the order they were added: the latency of all rules
+// that inject a fault is added up, the first one with an error or dropping the operation determines its outcome.
+func (f *FaultClient) AddRule(rule FaultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, &faultRule{FaultRule: rule})
+}
+
+// ClearRules removes all rules.
+func (f *FaultClient) ClearRules() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = nil
+}
+
+// Injected returns the total number of injected faults.
+func (f *FaultClient) Injected() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.injected
+}
+
+// faults determines the faults to inject into the given operation.
+func (f *FaultClient) faults(op Operation) (latency time.Duration, drop bool, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, rule := range f.rules {
+		if !op.Matches(rule.Filters...) {
+			continue
+		}
+
+		rule.selected++
+		if rule.selected <= rule.After ||
+			(rule.Times > 0 && rule.injected >= rule.Times) ||
+			(rule.Probability > 0 && rand.Float64() >= rule.Probability) {
+			continue
+		}
+
+		rule.injected++
+		f.injected++
+		latency += rule.Latency
+		if rule.Err != nil {
+			return latency, false, rule.Err
+		}
+		if rule.Drop && isWriteVerb(op.Verb) {
+			return latency, true, nil
+		}
+	}
+	return latency, false, nil
+}
+
+// inject injects the faults for the given operation. If it reports done, the operation must not be run
+// and the returned error is its result.
+func (f *FaultClient) inject(ctx context.Context, op Operation) (done bool, err error) {
+	latency, drop, err := f.faults(op)
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err != nil {
+		return true, err
+	}
+	return drop, nil
+}
+
+func (f *FaultClient) objectOperation(verb Verb, subResource string, obj client.Object, opts []any) Operation {
+	gvk, _ := f.GroupVersionKindFor(obj)
+	return Operation{
+		Verb:        verb,
+		SubResource: subResource,
+		GVK:         gvk,
+		Key:         client.ObjectKeyFromObject(obj),
+		Options:     opts,
+		Object:      obj.DeepCopyObject(),
+	}
+}
+
+func (f *FaultClient) patchOperation(subResource string, obj client.Object, patch client.Patch, opts []any) Operation {
+	op := f.objectOperation(patchVerb(patch), subResource, obj, opts)
+	op.PatchType = patch.Type()
+	if data, err := patch.Data(obj); err == nil {
+		op.PatchData = data
+	}
+	return op
+}
+
+func (f *FaultClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	gvk, _ := f.GroupVersionKindFor(obj)
+	if done, err := f.inject(ctx, Operation{Verb: VerbGet, GVK: gvk, Key: key, Options: toAnySlice(opts)}); done {
+		return err
+	}
+	return f.Client.Get(ctx, key, obj, opts...)
+}
+
+func (f *FaultClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	o := &client.ListOptions{}
+	o.ApplyOptions(opts)
+	op := Operation{
+		Verb:    VerbList,
+		GVK:     listItemGVK(f, list),
+		Key:     client.ObjectKey{Namespace: o.Namespace},
+		Options: toAnySlice(opts),
+	}
+	if done, err := f.inject(ctx, op); done {
+		return err
+	}
+	return f.Client.List(ctx, list, opts...)
+}
+
+func (f *FaultClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if done, err := f.inject(ctx, f.objectOperation(VerbCreate, "", obj, toAnySlice(opts))); done {
+		return err
+	}
+	return f.Client.Create(ctx, obj, opts...)
+}
+
+func (f *FaultClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if done, err := f.inject(ctx, f.objectOperation(VerbUpdate, "", obj, toAnySlice(opts))); done {
+		return err
+	}
+	return f.Client.Update(ctx, obj, opts...)
+}
+
+func (f *FaultClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if done, err := f.inject(ctx, f.patchOperation("", obj, patch, toAnySlice(opts))); done {
+		return err
+	}
+	return f.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (f *FaultClient) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	if done, err := f.inject(ctx, newApplyOperation("", obj, toAnySlice(opts))); done {
+		return err
+	}
+	return f.Client.Apply(ctx, obj, opts...)
+}
+
+func (f *FaultClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if done, err := f.inject(ctx, f.objectOperation(VerbDelete, "", obj, toAnySlice(opts))); done {
+		return err
+	}
+	return f.Client.Delete(ctx, obj, opts...)
+}
+
+func (f *FaultClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	gvk, _ := f.GroupVersionKindFor(obj)
+	o := &client.DeleteAllOfOptions{}
+	o.ApplyOptions(opts)
+	op := Operation{
+		Verb:    VerbDeleteAllOf,
+		GVK:     gvk,
+		Key:     client.ObjectKey{Namespace: o.Namespace},
+		Options: toAnySlice(opts),
+	}
+	if done, err := f.inject(ctx, op); done {
+		return err
+	}
+	return f.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (f *FaultClient) Status() client.SubResourceWriter {
+	return &faultSubResourceWriter{f.Client.Status(), f, "status"}
+}
+
+func (f *FaultClient) SubResource(subResource string) client.SubResourceClient {
+	c := f.Client.SubResource(subResource)
+	return &faultSubResourceClient{c, faultSubResourceWriter{c, f, subResource}}
+}
+
+type faultSubResourceWriter struct {
+	client.SubResourceWriter
+	f           *FaultClient
+	subResource string
+}
+
+func (w *faultSubResourceWriter) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	if done, err := w.f.inject(ctx, w.f.objectOperation(VerbCreate, w.subResource, obj, toAnySlice(opts))); done {
+		return err
+	}
+	return w.SubResourceWriter.Create(ctx, obj, subResource, opts...)
+}
+
+func (w *faultSubResourceWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if done, err := w.f.inject(ctx, w.f.objectOperation(VerbUpdate, w.subResource, obj, toAnySlice(opts))); done {
+		return err
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+func (w *faultSubResourceWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if done, err := w.f.inject(ctx, w.f.patchOperation(w.subResource, obj, patch, toAnySlice(opts))); done {
+		return err
+	}
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func (w *faultSubResourceWriter) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
+	if done, err := w.f.inject(ctx, newApplyOperation(w.subResource, obj, toAnySlice(opts))); done {
+		return err
+	}
+	return w.SubResourceWriter.Apply(ctx, obj, opts...)
+}
+
+type faultSubResourceClient struct {
+	reader client.SubResourceReader
+	faultSubResourceWriter
+}
+
+func (c *faultSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	if done, err := c.f.inject(ctx, c.f.objectOperation(VerbGet, c.subResource, obj, toAnySlice(opts))); done {
+		return err
+	}
+	return c.reader.Get(ctx, obj, subResource, opts...)
+}
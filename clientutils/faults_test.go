@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("FaultClient", func() {
+	var (
+		ctx    context.Context
+		fakeC  client.Client
+		cm     *corev1.ConfigMap
+		cmKind schema.GroupKind
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: corev1.NamespaceDefault,
+			Name:      "my-cm",
+			Labels:    map[string]string{"app": "foo"},
+		}}
+		cmKind = schema.GroupKind{Kind: "ConfigMap"}
+		fakeC = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm.DeepCopy()).Build()
+	})
+
+	It("should inject a conflict on the nth update of a kind", func() {
+		conflict := apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, cm.Name, nil)
+		c := NewFaultClient(fakeC, FaultRule{
+			Filters: []OperationFilter{OperationVerbs(VerbUpdate), OperationKind(cmKind)},
+			After:   1,
+			Times:   1,
+			Err:     conflict,
+		})
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		Expect(apierrors.IsConflict(c.Update(ctx, cm))).To(BeTrue())
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		Expect(c.Injected()).To(Equal(1))
+	})
+
+	It("should inject a timeout on list in a namespace", func() {
+		c := NewFaultClient(fakeC, FaultRule{
+			Filters: []OperationFilter{OperationVerbs(VerbList), OperationNamespace("flaky")},
+			Err:     apierrors.NewTimeoutError("list timed out", 1),
+		})
+
+		Expect(apierrors.IsTimeout(c.List(ctx, &corev1.ConfigMapList{}, client.InNamespace("flaky")))).To(BeTrue())
+		Expect(c.List(ctx, &corev1.ConfigMapList{}, client.InNamespace(corev1.NamespaceDefault))).To(Succeed())
+	})
+
+	It("should drop writes to objects matching the label selector", func() {
+		c := NewFaultClient(fakeC, FaultRule{
+			Filters: []OperationFilter{OperationLabelSelector(labels.SelectorFromSet(labels.Set{"app": "foo"}))},
+			Drop:    true,
+		})
+
+		Expect(c.Delete(ctx, cm)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})).To(Succeed())
+
+		other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "other"}}
+		Expect(c.Create(ctx, other)).To(Succeed())
+		Expect(fakeC.Get(ctx, client.ObjectKeyFromObject(other), &corev1.ConfigMap{})).To(Succeed())
+	})
+
+	It("should not select reads by the labels of the passed object", func() {
+		c := NewFaultClient(fakeC, FaultRule{
+			Filters: []OperationFilter{OperationLabelSelector(labels.SelectorFromSet(labels.Set{"app": "foo"}))},
+			Err:     errors.New("injected"),
+		})
+
+		reused := cm.DeepCopy()
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), reused)).To(Succeed())
+		Expect(c.List(ctx, &corev1.ConfigMapList{})).To(Succeed())
+		Expect(c.Update(ctx, reused)).To(MatchError("injected"))
+	})
+
+	It("should pass a copy of the object to the filters", func() {
+		c := NewFaultClient(fakeC, FaultRule{
+			Filters: []OperationFilter{func(op Operation) bool {
+				op.Object.(client.Object).SetLabels(nil)
+				return false
+			}},
+		})
+
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		Expect(cm.Labels).To(HaveKeyWithValue("app", "foo"))
+	})
+
+	It("should add artificial latency and respect the context", func() {
+		c := NewFaultClient(fakeC, FaultRule{Latency: 50 * time.Millisecond})
+
+		start := time.Now()
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		Expect(c.Get(cancelCtx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})).To(MatchError(context.Canceled))
+	})
+
+	It("should inject faults with the given probability", func() {
+		c := NewFaultClient(fakeC, FaultRule{
+			Probability: 0.5,
+			Err:         apierrors.NewServiceUnavailable("unavailable"),
+		})
+
+		failed := 0
+		for range 1000 {
+			if err := c.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); err != nil {
+				Expect(apierrors.IsServiceUnavailable(err)).To(BeTrue())
+				failed++
+			}
+		}
+		Expect(failed).To(BeNumerically("~", 500, 150))
+		Expect(c.Injected()).To(Equal(failed))
+	})
+
+	It("should inject faults into status writes and allow clearing the rules", func() {
+		c := NewFaultClient(fakeC, FaultRule{
+			Filters: []OperationFilter{OperationSubResource("status")},
+			Err:     apierrors.NewInternalError(errors.New("injected")),
+		})
+
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		Expect(apierrors.IsInternalError(c.Status().Update(ctx, cm))).To(BeTrue())
+
+		c.ClearRules()
+		Expect(apierrors.IsInternalError(c.Status().Update(ctx, cm))).To(BeFalse())
+		Expect(c.Injected()).To(Equal(1))
+	})
+})
@@ -6,7 +6,10 @@ package clientutils
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
 
+	"github.com/ironcore-dev/controller-utils/metautils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -17,8 +20,11 @@ import (
 )
 
 // SharedFieldIndexer allows registering and calling field index functions shared by different users.
+// It is safe for concurrent use.
 type SharedFieldIndexer struct {
 	indexer client.FieldIndexer
+
+	mu sync.Mutex
 	*sharedFieldIndexerMap
 }
 
@@ -32,7 +38,41 @@ func NewSharedFieldIndexer(indexer client.FieldIndexer, scheme *runtime.Scheme)
 
 // Register registers the client.IndexerFunc for the given client.Object and field.
 func (s *SharedFieldIndexer) Register(obj client.Object, field string, extractValue client.IndexerFunc) error {
-	updated, err := s.setIfNotPresent(obj, field, extractValue)
+	return s.register(obj, field, extractValue, "")
+}
+
+// MustRegister registers the client.IndexerFunc for the given client.Object and field.
+func (s *SharedFieldIndexer) MustRegister(obj client.Object, field string, extractValue client.IndexerFunc) {
+	utilruntime.Must(s.Register(obj, field, extractValue))
+}
+
+// RegisterIndex registers the given Index for the given client.Object.
+// In addition to Register, it errors if the same values are already indexed for the object under a different
+// field, e.g. when registering FieldPathIndex("spec.nodeName") and FieldPathIndex("spec.nodeName").Named("node").
+func (s *SharedFieldIndexer) RegisterIndex(obj client.Object, index Index) error {
+	return s.register(obj, index.Field, index.Extract, index.source)
+}
+
+// MustRegisterIndex registers the given Index for the given client.Object.
+func (s *SharedFieldIndexer) MustRegisterIndex(obj client.Object, index Index) {
+	utilruntime.Must(s.RegisterIndex(obj, index))
+}
+
+func (s *SharedFieldIndexer) register(obj client.Object, field string, extractValue client.IndexerFunc, source string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if source != "" {
+		other, err := s.fieldForSource(obj, source)
+		if err != nil {
+			return err
+		}
+		if other != "" && other != field {
+			return fmt.Errorf("indexer for type %T field %s indexes the same values as field %s", obj, field, other)
+		}
+	}
+
+	updated, err := s.setIfNotPresent(obj, field, extractValue, source)
 	if err != nil {
 		return err
 	}
@@ -42,30 +82,69 @@ func (s *SharedFieldIndexer) Register(obj client.Object, field string, extractVa
 	return nil
 }
 
-// MustRegister registers the client.IndexerFunc for the given client.Object and field.
-func (s *SharedFieldIndexer) MustRegister(obj client.Object, field string, extractValue client.IndexerFunc) {
-	utilruntime.Must(s.Register(obj, field, extractValue))
-}
-
 // IndexField calls a registered client.IndexerFunc for the given client.Object and field.
 // If the object / field is unknown or its GVK could not be determined, it errors.
+// The underlying indexer is called without holding the lock. Concurrent calls for the same object / field wait
+// for the ongoing call and retry if it failed.
 func (s *SharedFieldIndexer) IndexField(ctx context.Context, obj client.Object, field string) error {
+	s.mu.Lock()
 	entry, err := s.get(obj, field)
 	if err != nil {
+		s.mu.Unlock()
 		return err
 	}
-
 	if entry == nil {
+		s.mu.Unlock()
 		return fmt.Errorf("unknown field %s for type %T", field, obj)
 	}
 	if entry.initialized {
+		s.mu.Unlock()
 		return nil
 	}
-	if err := s.indexer.IndexField(ctx, obj, field, entry.extractValue); err != nil {
+	if indexing := entry.indexing; indexing != nil {
+		s.mu.Unlock()
+		select {
+		case <-indexing:
+			return s.IndexField(ctx, obj, field)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	indexing := make(chan struct{})
+	entry.indexing = indexing
+	s.mu.Unlock()
+
+	err = s.indexer.IndexField(ctx, obj, field, entry.extractValue)
+
+	s.mu.Lock()
+	entry.indexing = nil
+	entry.initialized = err == nil
+	s.mu.Unlock()
+	close(indexing)
+	return err
+}
+
+// ListByIndex lists the objects of the given list type whose index for the given field contains value,
+// using client.MatchingFields. It errors if no index is registered for the list's item type and field.
+// The index has to be added to the cache (see IndexField) before the cache is started.
+func (s *SharedFieldIndexer) ListByIndex(ctx context.Context, c client.Reader, list client.ObjectList, field, value string, opts ...client.ListOption) error {
+	obj, err := s.objectForList(list)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	entry, err := s.get(obj, field)
+	s.mu.Unlock()
+	if err != nil {
 		return err
 	}
-	entry.initialized = true
-	return nil
+	if entry == nil {
+		return fmt.Errorf("unknown field %s for type %T", field, obj)
+	}
+
+	opts = append(slices.Clip(opts), client.MatchingFields{field: value})
+	return c.List(ctx, list, opts...)
 }
 
 type sharedFieldIndexerMap struct {
@@ -85,8 +164,12 @@ func newSharedFieldIndexerMap(scheme *runtime.Scheme) *sharedFieldIndexerMap {
 }
 
 type mapEntry struct {
-	initialized  bool
+	initialized bool
+	// indexing is closed once an ongoing call to the underlying indexer returns. It is nil if there is none.
+	indexing     chan struct{}
 	extractValue client.IndexerFunc
+	// source identifies the indexed values, if known. See Index.
+	source string
 }
 
 type specificSharedFieldIndexerMap struct {
@@ -101,7 +184,16 @@ func (s *specificSharedFieldIndexerMap) get(gvk schema.GroupVersionKind, name st
 	return s.gvkToNameToEntry[gvk][name]
 }
 
-func (s *specificSharedFieldIndexerMap) setIfNotPresent(gvk schema.GroupVersionKind, name string, extractValue client.IndexerFunc) (updated bool) {
+func (s *specificSharedFieldIndexerMap) fieldForSource(gvk schema.GroupVersionKind, source string) string {
+	for name, entry := range s.gvkToNameToEntry[gvk] {
+		if entry.source == source {
+			return name
+		}
+	}
+	return ""
+}
+
+func (s *specificSharedFieldIndexerMap) setIfNotPresent(gvk schema.GroupVersionKind, name string, extractValue client.IndexerFunc, source string) (updated bool) {
 	nameToEntry := s.gvkToNameToEntry[gvk]
 	if nameToEntry == nil {
 		nameToEntry = make(map[string]*mapEntry)
@@ -111,7 +203,7 @@ func (s *specificSharedFieldIndexerMap) setIfNotPresent(gvk schema.GroupVersionK
 	if _, ok := nameToEntry[name]; ok {
 		return false
 	}
-	nameToEntry[name] = &mapEntry{extractValue: extractValue}
+	nameToEntry[name] = &mapEntry{extractValue: extractValue, source: source}
 	return true
 }
 
@@ -140,11 +232,49 @@ func (s *sharedFieldIndexerMap) get(obj client.Object, name string) (*mapEntry,
 	return m.get(gvk, name), nil
 }
 
-func (s *sharedFieldIndexerMap) setIfNotPresent(obj client.Object, name string, extractValue client.IndexerFunc) (updated bool, err error) {
+func (s *sharedFieldIndexerMap) fieldForSource(obj client.Object, source string) (string, error) {
+	m, gvk, err := s.mapFor(obj)
+	if err != nil {
+		return "", err
+	}
+
+	return m.fieldForSource(gvk, source), nil
+}
+
+func (s *sharedFieldIndexerMap) setIfNotPresent(obj client.Object, name string, extractValue client.IndexerFunc, source string) (updated bool, err error) {
 	m, gvk, err := s.mapFor(obj)
 	if err != nil {
 		return false, err
 	}
 
-	return m.setIfNotPresent(gvk, name, extractValue), nil
+	return m.setIfNotPresent(gvk, name, extractValue, source), nil
+}
+
+// objectForList returns an empty object of the item type of the given list.
+func (s *sharedFieldIndexerMap) objectForList(list client.ObjectList) (client.Object, error) {
+	gvk, err := metautils.GVKForList(s.scheme, list)
+	if err != nil {
+		return nil, err
+	}
+
+	switch list.(type) {
+	case *unstructured.UnstructuredList:
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		return obj, nil
+	case *metav1.PartialObjectMetadataList:
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(gvk)
+		return obj, nil
+	default:
+		runtimeObj, err := s.scheme.New(gvk)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := runtimeObj.(client.Object)
+		if !ok {
+			return nil, fmt.Errorf("type %T is not a client.Object", runtimeObj)
+		}
+		return obj, nil
+	}
 }
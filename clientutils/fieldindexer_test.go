@@ -5,6 +5,8 @@ package clientutils
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	mockclient "github.com/ironcore-dev/controller-utils/mock/controller-runtime/client"
 	. "github.com/onsi/ginkgo/v2"
@@ -15,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("FieldIndexer", func() {
@@ -81,6 +84,37 @@ var _ = Describe("FieldIndexer", func() {
 			Expect(idx.IndexField(ctx, &corev1.Pod{}, ".spec")).To(Succeed())
 		})
 
+		It("should not hold the lock while calling the underlying indexer", func() {
+			var (
+				started = make(chan struct{})
+				release = make(chan struct{})
+			)
+			fieldIndexer.EXPECT().IndexField(ctx, &corev1.Pod{}, ".spec", gomock.Any()).DoAndReturn(
+				func(context.Context, client.Object, string, client.IndexerFunc) error {
+					close(started)
+					<-release
+					return nil
+				}).Times(1)
+
+			idx := NewSharedFieldIndexer(fieldIndexer, scheme.Scheme)
+			Expect(idx.Register(&corev1.Pod{}, ".spec", func(client.Object) []string { return nil })).To(Succeed())
+
+			var wg sync.WaitGroup
+			for range 2 {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(idx.IndexField(ctx, &corev1.Pod{}, ".spec")).To(Succeed())
+				}()
+			}
+
+			<-started
+			Expect(idx.Register(&corev1.Pod{}, ".status", func(client.Object) []string { return nil })).To(Succeed())
+			close(release)
+			wg.Wait()
+		})
+
 		It("should work with unstructured objects", func() {
 			pod := &unstructured.Unstructured{
 				Object: map[string]interface{}{
@@ -142,5 +176,58 @@ var _ = Describe("FieldIndexer", func() {
 			idx := NewSharedFieldIndexer(fieldIndexer, scheme.Scheme)
 			Expect(idx.IndexField(ctx, &corev1.Pod{}, "unknown")).To(HaveOccurred())
 		})
+
+		It("should allow registering concurrently", func() {
+			idx := NewSharedFieldIndexer(fieldIndexer, scheme.Scheme)
+
+			var wg sync.WaitGroup
+			for i := range 10 {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(idx.Register(&corev1.Pod{}, fmt.Sprintf(".field%d", i), func(client.Object) []string { return nil })).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			for i := range 10 {
+				Expect(idx.Register(&corev1.Pod{}, fmt.Sprintf(".field%d", i), nil)).To(HaveOccurred())
+			}
+		})
+
+		It("should error if the same index is registered under different fields", func() {
+			idx := NewSharedFieldIndexer(fieldIndexer, scheme.Scheme)
+
+			Expect(idx.RegisterIndex(&corev1.Pod{}, FieldPathIndex("spec.nodeName"))).To(Succeed())
+			Expect(idx.RegisterIndex(&corev1.Pod{}, FieldPathIndex(".spec.nodeName").Named("node"))).To(MatchError(
+				"indexer for type *v1.Pod field node indexes the same values as field spec.nodeName",
+			))
+			Expect(idx.RegisterIndex(&corev1.Pod{}, FieldPathIndex("spec.nodeName"))).To(MatchError(
+				"indexer for type *v1.Pod field spec.nodeName already registered",
+			))
+			Expect(idx.RegisterIndex(&corev1.ConfigMap{}, FieldPathIndex("spec.nodeName").Named("node"))).To(Succeed())
+		})
+
+		It("should list by a registered index", func() {
+			idx := NewSharedFieldIndexer(fieldIndexer, scheme.Scheme)
+			index := FieldPathIndex("spec.nodeName")
+			Expect(idx.RegisterIndex(&corev1.Pod{}, index)).To(Succeed())
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithIndex(&corev1.Pod{}, index.Field, index.Extract).
+				WithObjects(
+					&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-1"}, Spec: corev1.PodSpec{NodeName: "node-1"}},
+					&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-2"}, Spec: corev1.PodSpec{NodeName: "node-2"}},
+				).
+				Build()
+
+			list := &corev1.PodList{}
+			Expect(idx.ListByIndex(ctx, c, list, "spec.nodeName", "node-1", client.InNamespace("default"))).To(Succeed())
+			Expect(list.Items).To(ConsistOf(HaveField("Name", "pod-1")))
+
+			Expect(idx.ListByIndex(ctx, c, list, "unknown", "node-1")).To(MatchError("unknown field unknown for type *v1.Pod"))
+		})
 	})
 })
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ControllerUIDField is the field ControllerUIDIndex is registered under.
+	ControllerUIDField = ".metadata.controller.uid"
+	// OwnerRefsField is the field OwnerRefIndex is registered under.
+	OwnerRefsField = ".metadata.ownerReferences"
+	// LabelKeysField is the field LabelKeysIndex is registered under.
+	LabelKeysField = ".metadata.labels.keys"
+)
+
+// Index is a field index that can be registered using SharedFieldIndexer.RegisterIndex.
+type Index struct {
+	// Field is the field the index is registered under.
+	Field string
+	// Extract extracts the indexed values from an object.
+	Extract client.IndexerFunc
+
+	// source identifies the indexed values. It is used to detect the same values being indexed
+	// under different fields.
+	source string
+}
+
+// Named returns a copy of the Index registered under the given field.
+func (i Index) Named(field string) Index {
+	i.Field = field
+	return i
+}
+
+// ControllerUIDIndex indexes objects by the UID of their controller, if any.
+func ControllerUIDIndex() Index {
+	return Index{
+		Field:  ControllerUIDField,
+		source: "controller-uid",
+		Extract: func(obj client.Object) []string {
+			controller := metav1.GetControllerOfNoCopy(obj)
+			if controller == nil {
+				return nil
+			}
+			return []string{string(controller.UID)}
+		},
+	}
+}
+
+// OwnerRefIndex indexes objects by their owner references. Use OwnerRefIndexValue to obtain the value
+// to look up objects owned by a given object.
+func OwnerRefIndex() Index {
+	return Index{
+		Field:  OwnerRefsField,
+		source: "owner-refs",
+		Extract: func(obj client.Object) []string {
+			ownerRefs := obj.GetOwnerReferences()
+			res := make([]string, 0, len(ownerRefs))
+			for _, ownerRef := range ownerRefs {
+				gv, err := schema.ParseGroupVersion(ownerRef.APIVersion)
+				if err != nil {
+					continue
+				}
+				res = append(res, OwnerRefIndexValue(ObjectRef{
+					GroupKind: schema.GroupKind{Group: gv.Group, Kind: ownerRef.Kind},
+					Key:       client.ObjectKey{Name: ownerRef.Name},
+				}))
+			}
+			return res
+		},
+	}
+}
+
+// OwnerRefIndexValue returns the value of OwnerRefIndex for objects owned by the referenced object.
+// Since owner references do not carry a namespace, the namespace of ref is ignored: when looking up dependents of
+// a namespaced owner, restrict the lookup to the owner's namespace (e.g. using client.InNamespace).
+func OwnerRefIndexValue(ref ObjectRef) string {
+	return ObjectRef{GroupKind: ref.GroupKind, Key: client.ObjectKey{Name: ref.Key.Name}}.String()
+}
+
+// LabelKeysIndex indexes objects by the keys of their labels, which allows looking up all objects that have
+// a label with a given key, regardless of its value.
+func LabelKeysIndex() Index {
+	return Index{
+		Field:  LabelKeysField,
+		source: "label-keys",
+		Extract: func(obj client.Object) []string {
+			labels := obj.GetLabels()
+			res := make([]string, 0, len(labels))
+			for key := range labels {
+				res = append(res, key)
+			}
+			return res
+		},
+	}
+}
+
+// FieldPathIndex indexes objects by the value at the given dot-separated field path, e.g. 'spec.nodeName'.
+// The index is registered under the field path. Scalar values are indexed by their string representation,
+// lists of scalars by each of their items. Empty and missing values are not indexed.
+//
+// Typed objects are converted to unstructured for every call, so prefer a dedicated client.IndexerFunc
+// for performance-sensitive indexes.
+func FieldPathIndex(path string) Index {
+	fields := strings.Split(strings.TrimPrefix(path, "."), ".")
+	return Index{
+		Field:  path,
+		source: "field:" + strings.Join(fields, "."),
+		Extract: func(obj client.Object) []string {
+			var content map[string]any
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				content = u.UnstructuredContent()
+			} else {
+				var err error
+				content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+				if err != nil {
+					return nil
+				}
+			}
+
+			value, found, err := unstructured.NestedFieldNoCopy(content, fields...)
+			if err != nil || !found {
+				return nil
+			}
+			return indexValues(value)
+		},
+	}
+}
+
+func indexValues(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		var res []string
+		for _, item := range v {
+			res = append(res, indexValues(item)...)
+		}
+		return res
+	case map[string]any:
+		return nil
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
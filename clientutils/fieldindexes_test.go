@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("FieldIndexes", func() {
+	var pod *corev1.Pod
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: corev1.NamespaceDefault,
+				Name:      "my-pod",
+				Labels:    map[string]string{"app": "foo", "tier": "web"},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "my-rs", UID: "rs-uid", Controller: ptr.To(true)},
+					{APIVersion: "v1", Kind: "ConfigMap", Name: "my-cm", UID: "cm-uid"},
+				},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "my-node",
+				Containers: []corev1.Container{
+					{Name: "a", Ports: []corev1.ContainerPort{{ContainerPort: 80}}},
+					{Name: "b"},
+				},
+			},
+		}
+	})
+
+	Describe("ControllerUIDIndex", func() {
+		It("should index the controller uid", func() {
+			Expect(ControllerUIDIndex().Extract(pod)).To(Equal([]string{"rs-uid"}))
+			Expect(ControllerUIDIndex().Extract(&corev1.Pod{})).To(BeEmpty())
+		})
+	})
+
+	Describe("OwnerRefIndex", func() {
+		It("should index all owner references", func() {
+			rsRef := ObjectRef{
+				GroupKind: schema.GroupKind{Group: "apps", Kind: "ReplicaSet"},
+				Key:       client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "my-rs"},
+			}
+			Expect(OwnerRefIndex().Extract(pod)).To(Equal([]string{
+				OwnerRefIndexValue(rsRef),
+				"ConfigMap/my-cm",
+			}))
+		})
+	})
+
+	Describe("LabelKeysIndex", func() {
+		It("should index the label keys", func() {
+			Expect(LabelKeysIndex().Extract(pod)).To(ConsistOf("app", "tier"))
+		})
+	})
+
+	Describe("FieldPathIndex", func() {
+		It("should index scalar values at the field path", func() {
+			index := FieldPathIndex("spec.nodeName")
+			Expect(index.Field).To(Equal("spec.nodeName"))
+			Expect(index.Extract(pod)).To(Equal([]string{"my-node"}))
+			Expect(index.Extract(&corev1.Pod{})).To(BeEmpty())
+		})
+
+		It("should index list items and non-string scalars", func() {
+			Expect(FieldPathIndex("spec.containers").Extract(pod)).To(BeEmpty())
+			Expect(FieldPathIndex(".metadata.finalizers").Extract(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Finalizers: []string{"a", "b"}},
+			})).To(Equal([]string{"a", "b"}))
+			Expect(FieldPathIndex("spec.hostNetwork").Extract(&corev1.Pod{
+				Spec: corev1.PodSpec{HostNetwork: true},
+			})).To(Equal([]string{"true"}))
+		})
+
+		It("should work with unstructured objects", func() {
+			u := &unstructured.Unstructured{Object: map[string]any{
+				"spec": map[string]any{"nodeName": "my-node"},
+			}}
+			Expect(FieldPathIndex("spec.nodeName").Extract(u)).To(Equal([]string{"my-node"}))
+		})
+	})
+})
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DefaultFinalizerRequeueAfter is the default duration after which a Finalizer requeues an object
+// whose cleanup is not done yet.
+const DefaultFinalizerRequeueAfter = 5 * time.Second
+
+// CleanupFunc cleans up after an object that is being deleted.
+// It reports done once the cleanup is complete. As long as any registered cleanup is not done, the object is
+// requeued and all cleanups are run again on the next reconciliation, including the ones that already reported
+// done. Cleanups therefore have to be idempotent, e.g. by checking whether there is anything left to clean up.
+type CleanupFunc func(ctx context.Context, obj client.Object) (done bool, err error)
+
+// FinalizerOptions are options for a Finalizer.
+type FinalizerOptions struct {
+	// RequeueAfter is the duration after which an object whose cleanup is not done yet is requeued.
+	// Defaults to DefaultFinalizerRequeueAfter.
+	RequeueAfter time.Duration
+}
+
+// ApplyToFinalizer implements FinalizerOption.
+func (o *FinalizerOptions) ApplyToFinalizer(o2 *FinalizerOptions) {
+	if o.RequeueAfter > 0 {
+		o2.RequeueAfter = o.RequeueAfter
+	}
+}
+
+// ApplyOptions applies all FinalizerOption to this FinalizerOptions.
+func (o *FinalizerOptions) ApplyOptions(opts []FinalizerOption) {
+	for _, opt := range opts {
+		opt.ApplyToFinalizer(o)
+	}
+}
+
+// FinalizerOption is an option to NewFinalizer.
+type FinalizerOption interface {
+	// ApplyToFinalizer modifies the underlying FinalizerOptions.
+	ApplyToFinalizer(o *FinalizerOptions)
+}
+
+// FinalizerRequeueAfter sets FinalizerOptions.RequeueAfter.
+type FinalizerRequeueAfter time.Duration
+
+// ApplyToFinalizer implements FinalizerOption.
+func (d FinalizerRequeueAfter) ApplyToFinalizer(o *FinalizerOptions) {
+	o.RequeueAfter = time.Duration(d)
+}
+
+type namedCleanup struct {
+	name    string
+	cleanup CleanupFunc
+}
+
+// Finalizer manages the lifecycle of a finalizer on objects of a reconciler.
+//
+// It ensures the finalizer is present on live objects. Once an object is being deleted, it runs all registered
+// cleanups on every reconciliation and only removes the finalizer after all of them report done in the same pass.
+type Finalizer struct {
+	c            client.Client
+	finalizer    string
+	requeueAfter time.Duration
+	cleanups     []namedCleanup
+}
+
+// NewFinalizer creates a new Finalizer managing the given finalizer.
+func NewFinalizer(c client.Client, finalizer string, opts ...FinalizerOption) *Finalizer {
+	o := &FinalizerOptions{RequeueAfter: DefaultFinalizerRequeueAfter}
+	o.ApplyOptions(opts)
+	return &Finalizer{
+		c:            c,
+		finalizer:    finalizer,
+		requeueAfter: o.RequeueAfter,
+	}
+}
+
+// Finalizer returns the finalizer managed by this Finalizer.
+func (f *Finalizer) Finalizer() string {
+	return f.finalizer
+}
+
+// Register registers a cleanup with the given name.
+// Cleanups are run in the order they were registered. Register is not safe for concurrent use and
+// should be called before reconciling.
+func (f *Finalizer) Register(name string, cleanup CleanupFunc) error {
+	for _, c := range f.cleanups {
+		if c.name == name {
+			return fmt.Errorf("cleanup %q is already registered", name)
+		}
+	}
+	f.cleanups = append(f.cleanups, namedCleanup{name: name, cleanup: cleanup})
+	return nil
+}
+
+// Reconcile reconciles the finalizer of the given object.
+//
+// If the object is not being deleted, Reconcile ensures the finalizer is present and returns deleting=false,
+// indicating the caller should continue its regular reconciliation.
+//
+// If the object is being deleted, Reconcile runs all registered cleanups and returns deleting=true. If any cleanup
+// is not done yet, the returned result requeues the object. Once all cleanups are done, the finalizer is removed.
+//
+// If deleting is true or err is not nil, the caller should stop its reconciliation and return res and err.
+// Otherwise, res is empty and the caller continues its regular reconciliation.
+func (f *Finalizer) Reconcile(ctx context.Context, obj client.Object) (res reconcile.Result, deleting bool, err error) {
+	if obj.GetDeletionTimestamp().IsZero() {
+		if _, err := PatchEnsureFinalizer(ctx, f.c, obj, f.finalizer); err != nil {
+			return reconcile.Result{}, false, fmt.Errorf("error ensuring finalizer: %w", err)
+		}
+		return reconcile.Result{}, false, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(obj, f.finalizer) {
+		return reconcile.Result{}, true, nil
+	}
+
+	done, err := f.cleanup(ctx, obj)
+	if err != nil {
+		return reconcile.Result{}, true, err
+	}
+	if !done {
+		return reconcile.Result{RequeueAfter: f.requeueAfter}, true, nil
+	}
+
+	if _, err := PatchEnsureNoFinalizer(ctx, f.c, obj, f.finalizer); err != nil {
+		return reconcile.Result{}, true, fmt.Errorf("error removing finalizer: %w", err)
+	}
+	return reconcile.Result{}, true, nil
+}
+
+// cleanup runs all registered cleanups and reports whether all of them are done.
+func (f *Finalizer) cleanup(ctx context.Context, obj client.Object) (bool, error) {
+	allDone := true
+	for _, c := range f.cleanups {
+		done, err := c.cleanup(ctx, obj)
+		if err != nil {
+			return false, fmt.Errorf("error running cleanup %s: %w", c.name, err)
+		}
+		if !done {
+			allDone = false
+		}
+	}
+	return allDone, nil
+}
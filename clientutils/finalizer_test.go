@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Finalizer", func() {
+	const finalizerName = "example.org/finalizer"
+
+	var (
+		ctx context.Context
+		c   client.Client
+		cm  *corev1.ConfigMap
+		f   *Finalizer
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-cm"}}).
+			Build()
+		cm = &corev1.ConfigMap{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "my-cm"}, cm)).To(Succeed())
+		f = NewFinalizer(c, finalizerName, FinalizerRequeueAfter(time.Second))
+	})
+
+	reconcileDeleted := func() (reconcile.Result, error) {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		res, deleting, err := f.Reconcile(ctx, cm)
+		Expect(deleting).To(BeTrue())
+		return res, err
+	}
+
+	It("should reject registering a cleanup twice", func() {
+		cleanup := func(context.Context, client.Object) (bool, error) { return true, nil }
+		Expect(f.Register("foo", cleanup)).To(Succeed())
+		Expect(f.Register("foo", cleanup)).To(MatchError(ContainSubstring(`"foo" is already registered`)))
+	})
+
+	It("should add the finalizer to live objects", func() {
+		res, deleting, err := f.Reconcile(ctx, cm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleting).To(BeFalse())
+		Expect(res).To(Equal(reconcile.Result{}))
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		Expect(cm.Finalizers).To(Equal([]string{finalizerName}))
+	})
+
+	It("should run all cleanups and remove the finalizer once all of them are done", func() {
+		var fooCalls, barCalls int
+		Expect(f.Register("foo", func(context.Context, client.Object) (bool, error) {
+			fooCalls++
+			return true, nil
+		})).To(Succeed())
+		Expect(f.Register("bar", func(context.Context, client.Object) (bool, error) {
+			barCalls++
+			return barCalls >= 2, nil
+		})).To(Succeed())
+
+		_, _, err := f.Reconcile(ctx, cm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Delete(ctx, cm)).To(Succeed())
+
+		By("requeueing while a cleanup is not done")
+		res, err := reconcileDeleted()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{RequeueAfter: time.Second}))
+		Expect(cm.Finalizers).To(Equal([]string{finalizerName}))
+
+		By("removing the finalizer once all cleanups are done")
+		res, err = reconcileDeleted()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+		Expect(fooCalls).To(Equal(2), "all cleanups should run again until all of them are done")
+		Expect(barCalls).To(Equal(2))
+		Expect(apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(cm), cm))).To(BeTrue())
+	})
+
+	It("should keep the finalizer if a cleanup fails", func() {
+		cleanupErr := errors.New("cleanup failed")
+		Expect(f.Register("foo", func(context.Context, client.Object) (bool, error) {
+			return false, cleanupErr
+		})).To(Succeed())
+
+		_, _, err := f.Reconcile(ctx, cm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Delete(ctx, cm)).To(Succeed())
+
+		_, err = reconcileDeleted()
+		Expect(err).To(MatchError(cleanupErr))
+		Expect(err).To(MatchError(ContainSubstring("error running cleanup foo")))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		Expect(cm.Finalizers).To(Equal([]string{finalizerName}))
+	})
+})
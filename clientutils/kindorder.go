@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KindOrder determines the order in which objects of different kinds are processed.
+type KindOrder interface {
+	// KindPriority returns the priority of the given schema.GroupKind.
+	// Kinds with a lower priority are created first and deleted last.
+	KindPriority(gk schema.GroupKind) int
+}
+
+// KindOrderFunc is a function that implements KindOrder.
+type KindOrderFunc func(gk schema.GroupKind) int
+
+// KindPriority implements KindOrder.
+func (f KindOrderFunc) KindPriority(gk schema.GroupKind) int {
+	return f(gk)
+}
+
+// KindPriorities is a KindOrder backed by a table of priorities.
+// Kinds not present in the table are processed last.
+type KindPriorities map[schema.GroupKind]int
+
+// KindPriority implements KindOrder.
+func (p KindPriorities) KindPriority(gk schema.GroupKind) int {
+	if prio, ok := p[gk]; ok {
+		return prio
+	}
+	return math.MaxInt
+}
+
+// NewKindPriorities creates KindPriorities where each tier of group kinds has the priority of its index.
+func NewKindPriorities(tiers ...[]schema.GroupKind) KindPriorities {
+	p := make(KindPriorities)
+	for prio, tier := range tiers {
+		for _, gk := range tier {
+			p[gk] = prio
+		}
+	}
+	return p
+}
+
+// DefaultKindOrder orders namespaces first, followed by custom resource definitions, cluster-wide
+// configuration, service accounts and RBAC, configuration and storage, services, workloads and admission
+// webhooks. Any other kind, e.g. custom resources, comes last.
+var DefaultKindOrder KindOrder = NewKindPriorities(
+	[]schema.GroupKind{
+		{Group: corev1.GroupName, Kind: "Namespace"},
+	},
+	[]schema.GroupKind{
+		{Group: apiextensionsv1.GroupName, Kind: "CustomResourceDefinition"},
+	},
+	[]schema.GroupKind{
+		{Group: corev1.GroupName, Kind: "ResourceQuota"},
+		{Group: corev1.GroupName, Kind: "LimitRange"},
+		{Group: schedulingv1.GroupName, Kind: "PriorityClass"},
+	},
+	[]schema.GroupKind{
+		{Group: corev1.GroupName, Kind: "ServiceAccount"},
+	},
+	[]schema.GroupKind{
+		{Group: rbacv1.GroupName, Kind: "ClusterRole"},
+		{Group: rbacv1.GroupName, Kind: "ClusterRoleBinding"},
+		{Group: rbacv1.GroupName, Kind: "Role"},
+		{Group: rbacv1.GroupName, Kind: "RoleBinding"},
+	},
+	[]schema.GroupKind{
+		{Group: corev1.GroupName, Kind: "ConfigMap"},
+		{Group: corev1.GroupName, Kind: "Secret"},
+		{Group: storagev1.GroupName, Kind: "StorageClass"},
+		{Group: corev1.GroupName, Kind: "PersistentVolume"},
+		{Group: corev1.GroupName, Kind: "PersistentVolumeClaim"},
+	},
+	[]schema.GroupKind{
+		{Group: corev1.GroupName, Kind: "Service"},
+	},
+	[]schema.GroupKind{
+		{Group: corev1.GroupName, Kind: "Pod"},
+		{Group: corev1.GroupName, Kind: "ReplicationController"},
+		{Group: appsv1.GroupName, Kind: "DaemonSet"},
+		{Group: appsv1.GroupName, Kind: "Deployment"},
+		{Group: appsv1.GroupName, Kind: "ReplicaSet"},
+		{Group: appsv1.GroupName, Kind: "StatefulSet"},
+		{Group: batchv1.GroupName, Kind: "Job"},
+		{Group: batchv1.GroupName, Kind: "CronJob"},
+	},
+	[]schema.GroupKind{
+		{Group: networkingv1.GroupName, Kind: "Ingress"},
+		{Group: networkingv1.GroupName, Kind: "NetworkPolicy"},
+	},
+	[]schema.GroupKind{
+		{Group: admissionregistrationv1.GroupName, Kind: "MutatingWebhookConfiguration"},
+		{Group: admissionregistrationv1.GroupName, Kind: "ValidatingWebhookConfiguration"},
+	},
+)
+
+// groupByKind returns the indices of the given objects stably sorted and grouped by the priority of their kind.
+// If reverse is true, groups with a higher priority come first.
+func groupByKind(c clientMeta, order KindOrder, objs []client.Object, reverse bool) ([][]int, error) {
+	prios := make([]int, len(objs))
+	indices := make([]int, len(objs))
+	for i, obj := range objs {
+		gvk, err := c.GroupVersionKindFor(obj)
+		if err != nil {
+			return nil, fmt.Errorf("[object %d]: error getting group version kind: %w", i, err)
+		}
+
+		prios[i] = order.KindPriority(gvk.GroupKind())
+		indices[i] = i
+	}
+
+	sort.SliceStable(indices, func(i, j int) bool {
+		if reverse {
+			return prios[indices[i]] > prios[indices[j]]
+		}
+		return prios[indices[i]] < prios[indices[j]]
+	})
+
+	var groups [][]int
+	for n, i := range indices {
+		if n == 0 || prios[i] != prios[indices[n-1]] {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], i)
+	}
+	return groups, nil
+}
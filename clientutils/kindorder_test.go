@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	mockclient "github.com/ironcore-dev/controller-utils/mock/controller-runtime/client"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+var _ = Describe("KindOrder", func() {
+	var (
+		ctx  context.Context
+		ctrl *gomock.Controller
+		c    *mockclient.MockClient
+
+		ns         *corev1.Namespace
+		deployment *appsv1.Deployment
+		cm         *corev1.ConfigMap
+		custom     *unstructured.Unstructured
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		ctrl = gomock.NewController(GinkgoT())
+		c = mockclient.NewMockClient(ctrl)
+		c.EXPECT().GroupVersionKindFor(gomock.Any()).DoAndReturn(func(obj runtime.Object) (schema.GroupVersionKind, error) {
+			return apiutil.GVKForObject(obj, scheme.Scheme)
+		}).AnyTimes()
+
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-ns"}}
+		deployment = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-deployment"}}
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "my-ns", Name: "my-cm"}}
+		custom = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "example.org/v1",
+				"kind":       "Widget",
+				"metadata": map[string]interface{}{
+					"namespace": "my-ns",
+					"name":      "my-widget",
+				},
+			},
+		}
+	})
+
+	Describe("KindPriorities", func() {
+		It("should return the priority of known kinds and sort unknown kinds last", func() {
+			p := NewKindPriorities(
+				[]schema.GroupKind{{Kind: "Namespace"}},
+				[]schema.GroupKind{{Kind: "ConfigMap"}, {Kind: "Secret"}},
+			)
+			Expect(p.KindPriority(schema.GroupKind{Kind: "Namespace"})).To(Equal(0))
+			Expect(p.KindPriority(schema.GroupKind{Kind: "Secret"})).To(Equal(1))
+			Expect(p.KindPriority(schema.GroupKind{Group: "example.org", Kind: "Widget"})).
+				To(BeNumerically(">", p.KindPriority(schema.GroupKind{Kind: "Secret"})))
+		})
+	})
+
+	Describe("CreateMultiple", func() {
+		It("should create the objects in kind order", func() {
+			gomock.InOrder(
+				c.EXPECT().Create(ctx, ns),
+				c.EXPECT().Create(ctx, cm),
+				c.EXPECT().Create(ctx, deployment),
+				c.EXPECT().Create(ctx, custom),
+			)
+
+			Expect(CreateMultiple(ctx, c, []client.Object{custom, deployment, cm, ns}, OrderByKind{})).To(Succeed())
+		})
+
+		It("should use a custom kind order and pass through the remaining options", func() {
+			order := KindOrderFunc(func(gk schema.GroupKind) int {
+				if gk.Kind == "Widget" {
+					return 0
+				}
+				return 1
+			})
+			gomock.InOrder(
+				c.EXPECT().Create(ctx, custom, client.DryRunAll),
+				c.EXPECT().Create(ctx, ns, client.DryRunAll),
+			)
+
+			Expect(CreateMultiple(ctx, c, []client.Object{ns, custom}, OrderByKind{Order: order}, client.DryRunAll)).To(Succeed())
+		})
+	})
+
+	Describe("DeleteMultiple", func() {
+		It("should delete the objects in reverse kind order", func() {
+			gomock.InOrder(
+				c.EXPECT().Delete(ctx, custom),
+				c.EXPECT().Delete(ctx, deployment),
+				c.EXPECT().Delete(ctx, cm),
+				c.EXPECT().Delete(ctx, ns),
+			)
+
+			Expect(DeleteMultiple(ctx, c, []client.Object{ns, cm, deployment, custom}, OrderByKind{})).To(Succeed())
+		})
+	})
+})
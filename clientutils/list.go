@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/ironcore-dev/controller-utils/metautils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultListPageSize is the page size used by ListPages if no client.Limit is specified.
+const DefaultListPageSize int64 = 500
+
+// continueNotSupported is the continue token the controller-runtime cache sets on every list. The cache cuts
+// its result at the limit and rejects any continue token, so lists returning it cannot be paginated.
+const continueNotSupported = "continue-not-supported"
+
+// ListPages returns an iterator over the pages of a paginated list call.
+//
+// The page size is taken from the client.Limit option, defaulting to DefaultListPageSize. Subsequent pages are
+// requested using the continue token of the previous page until the server returns no more continue token.
+// Each page is a new list of the same type as the given list; the given list itself is not modified.
+// If a list call fails, the error is yielded and the iteration ends. Pages are only fetched on demand, so
+// stopping the iteration early does not fetch the remaining pages.
+//
+// Supported readers are the ones paginating via client.Limit and client.Continue (e.g. an uncached client
+// talking to the API server or the API reader of a manager) and the controller-runtime cache (including
+// the default client of a manager, which reads from it). The cache does not paginate: it cuts its result at the
+// limit and reports the continue token "continue-not-supported". If the first page is cut this way, it is
+// listed again without limit and all items are yielded in that single page. Other readers that ignore
+// client.Limit return all items in a single page as well.
+func ListPages(ctx context.Context, c client.Reader, list client.ObjectList, opts ...client.ListOption) iter.Seq2[client.ObjectList, error] {
+	return func(yield func(client.ObjectList, error) bool) {
+		o := &client.ListOptions{}
+		o.ApplyOptions(opts)
+		limit := o.Limit
+		if limit <= 0 {
+			limit = DefaultListPageSize
+		}
+
+		template := list.DeepCopyObject().(client.ObjectList)
+		if err := metautils.SetList(template, nil); err != nil {
+			yield(nil, fmt.Errorf("error resetting list: %w", err))
+			return
+		}
+
+		var continueToken string
+		for {
+			page := template.DeepCopyObject().(client.ObjectList)
+			pageOpts := append(slices.Clip(opts), client.Limit(limit), client.Continue(continueToken))
+			if err := c.List(ctx, page, pageOpts...); err != nil {
+				yield(nil, fmt.Errorf("error listing page: %w", err))
+				return
+			}
+
+			continueToken = page.GetContinue()
+			if continueToken == continueNotSupported {
+				if int64(meta.LenList(page)) >= limit {
+					page = template.DeepCopyObject().(client.ObjectList)
+					if err := c.List(ctx, page, append(slices.Clip(opts), client.Limit(0))...); err != nil {
+						yield(nil, fmt.Errorf("error listing without limit: %w", err))
+						return
+					}
+				}
+				page.SetContinue("")
+				continueToken = ""
+			}
+
+			if !yield(page, nil) {
+				return
+			}
+
+			if continueToken == "" {
+				return
+			}
+		}
+	}
+}
+
+// ListItems returns an iterator over the items of all pages of a paginated list call, see ListPages.
+// Pages are only fetched once all items of the previous page have been consumed.
+func ListItems(ctx context.Context, c client.Reader, list client.ObjectList, opts ...client.ListOption) iter.Seq2[client.Object, error] {
+	return func(yield func(client.Object, error) bool) {
+		for page, err := range ListPages(ctx, c, list, opts...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for obj, err := range metautils.ListItems(page) {
+				if !yield(obj, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ListAndFilterItems returns an iterator over the items of all pages of a paginated list call that match
+// the given filter function, see ListItems.
+func ListAndFilterItems(ctx context.Context, c client.Reader, list client.ObjectList, filterFunc func(obj client.Object) bool, opts ...client.ListOption) iter.Seq2[client.Object, error] {
+	return metautils.FilterItems(ListItems(ctx, c, list, opts...), filterFunc)
+}
+
+// ListAll lists all pages of a paginated list call into the given list, see ListPages.
+// The resource version of the list is set to the one of the first page, which is the one all pages are
+// consistent with.
+func ListAll(ctx context.Context, c client.Reader, list client.ObjectList, opts ...client.ListOption) error {
+	var (
+		objs            []client.Object
+		resourceVersion string
+		first           = true
+	)
+	for page, err := range ListPages(ctx, c, list, opts...) {
+		if err != nil {
+			return err
+		}
+
+		if first {
+			resourceVersion = page.GetResourceVersion()
+			first = false
+		}
+
+		pageObjs, err := metautils.ExtractList(page)
+		if err != nil {
+			return fmt.Errorf("error extracting list: %w", err)
+		}
+		objs = append(objs, pageObjs...)
+	}
+
+	if err := metautils.SetList(list, objs); err != nil {
+		return fmt.Errorf("error setting list: %w", err)
+	}
+	list.SetResourceVersion(resourceVersion)
+	list.SetContinue("")
+	return nil
+}
@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	"github.com/ironcore-dev/controller-utils/metautils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("List", func() {
+	var (
+		ctx       context.Context
+		c         client.Client
+		listCalls int
+		listErr   error
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		listCalls = 0
+		listErr = nil
+
+		var objs []client.Object
+		for i := range 5 {
+			objs = append(objs, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Namespace: corev1.NamespaceDefault,
+				Name:      fmt.Sprintf("cm-%d", i),
+			}})
+		}
+
+		// The fake client does not support pagination, so paginate the full result using the offset as continue token.
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(objs...).
+			WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					listCalls++
+					if listErr != nil {
+						return listErr
+					}
+					if err := c.List(ctx, list, opts...); err != nil {
+						return err
+					}
+
+					o := &client.ListOptions{}
+					o.ApplyOptions(opts)
+					objs := metautils.MustExtractList(list)
+					start := 0
+					if o.Continue != "" {
+						start, _ = strconv.Atoi(o.Continue)
+					}
+					end := min(start+int(o.Limit), len(objs))
+					list.SetContinue("")
+					if end < len(objs) {
+						list.SetContinue(strconv.Itoa(end))
+					}
+					return metautils.SetList(list, objs[start:end])
+				},
+			}).
+			Build()
+	})
+
+	Describe("ListPages", func() {
+		It("should follow the continue tokens until all pages are listed", func() {
+			var pageSizes []int
+			for page, err := range ListPages(ctx, c, &corev1.ConfigMapList{}, client.Limit(2)) {
+				Expect(err).NotTo(HaveOccurred())
+				pageSizes = append(pageSizes, len(page.(*corev1.ConfigMapList).Items))
+			}
+			Expect(pageSizes).To(Equal([]int{2, 2, 1}))
+			Expect(listCalls).To(Equal(3))
+		})
+
+		It("should not fetch the remaining pages when stopping early", func() {
+			for range ListPages(ctx, c, &corev1.ConfigMapList{}, client.Limit(2)) {
+				break
+			}
+			Expect(listCalls).To(Equal(1))
+		})
+
+		It("should yield list errors", func() {
+			listErr = errors.New("list error")
+			for _, err := range ListPages(ctx, c, &corev1.ConfigMapList{}) {
+				Expect(err).To(MatchError(listErr))
+			}
+			Expect(listCalls).To(Equal(1))
+		})
+	})
+
+	Describe("ListItems", func() {
+		It("should yield the items of all pages", func() {
+			var names []string
+			for obj, err := range ListItems(ctx, c, &corev1.ConfigMapList{}, client.Limit(2)) {
+				Expect(err).NotTo(HaveOccurred())
+				names = append(names, obj.GetName())
+			}
+			Expect(names).To(Equal([]string{"cm-0", "cm-1", "cm-2", "cm-3", "cm-4"}))
+		})
+
+		It("should only fetch the pages that are consumed", func() {
+			var names []string
+			for obj, err := range ListItems(ctx, c, &corev1.ConfigMapList{}, client.Limit(2)) {
+				Expect(err).NotTo(HaveOccurred())
+				names = append(names, obj.GetName())
+				if len(names) == 3 {
+					break
+				}
+			}
+			Expect(names).To(Equal([]string{"cm-0", "cm-1", "cm-2"}))
+			Expect(listCalls).To(Equal(2))
+		})
+	})
+
+	Describe("ListAndFilterItems", func() {
+		It("should only yield the matching items", func() {
+			var names []string
+			for obj, err := range ListAndFilterItems(ctx, c, &corev1.ConfigMapList{}, func(obj client.Object) bool {
+				return obj.GetName() != "cm-2"
+			}, client.Limit(2)) {
+				Expect(err).NotTo(HaveOccurred())
+				names = append(names, obj.GetName())
+			}
+			Expect(names).To(Equal([]string{"cm-0", "cm-1", "cm-3", "cm-4"}))
+		})
+	})
+
+	Describe("ListAll", func() {
+		It("should list all pages into the list", func() {
+			list := &corev1.ConfigMapList{}
+			Expect(ListAll(ctx, c, list, client.Limit(2))).To(Succeed())
+			Expect(list.Items).To(HaveLen(5))
+			Expect(list.Continue).To(BeEmpty())
+			Expect(listCalls).To(Equal(3))
+		})
+
+		Context("with a reader that does not paginate like the controller-runtime cache", func() {
+			var limits []int64
+			BeforeEach(func() {
+				limits = nil
+				base := c
+				// Like the controller-runtime cache, cut the result at the limit, always report the
+				// continue-not-supported token and reject any continue token.
+				c = fake.NewClientBuilder().
+					WithScheme(scheme.Scheme).
+					WithInterceptorFuncs(interceptor.Funcs{
+						List: func(ctx context.Context, _ client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+							o := &client.ListOptions{}
+							o.ApplyOptions(opts)
+							if o.Continue != "" {
+								return errors.New("continue list option is not supported by the cache")
+							}
+							limits = append(limits, o.Limit)
+							if err := base.List(ctx, list, client.InNamespace(o.Namespace), client.Limit(1000)); err != nil {
+								return err
+							}
+
+							objs := metautils.MustExtractList(list)
+							if o.Limit > 0 && int64(len(objs)) > o.Limit {
+								objs = objs[:o.Limit]
+							}
+							list.SetContinue("continue-not-supported")
+							return metautils.SetList(list, objs)
+						},
+					}).
+					Build()
+			})
+
+			It("should list all items if they fit into the first page", func() {
+				list := &corev1.ConfigMapList{}
+				Expect(ListAll(ctx, c, list)).To(Succeed())
+				Expect(list.Items).To(HaveLen(5))
+				Expect(list.Continue).To(BeEmpty())
+				Expect(limits).To(Equal([]int64{DefaultListPageSize}))
+			})
+
+			It("should list again without limit if the first page was cut", func() {
+				list := &corev1.ConfigMapList{}
+				Expect(ListAll(ctx, c, list, client.Limit(2))).To(Succeed())
+				Expect(list.Items).To(HaveLen(5))
+				Expect(list.Continue).To(BeEmpty())
+				Expect(limits).To(Equal([]int64{2, 0}))
+			})
+		})
+	})
+})
@@ -0,0 +1,379 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/ironcore-dev/controller-utils/metautils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	// MirrorLabel is the label on every object created by a Mirror in the target cluster containing the
+	// name of the Mirror.
+	MirrorLabel = "controller-utils.ironcore.dev/mirror"
+	// MirrorSourceAnnotation is the annotation on every object created by a Mirror in the target cluster
+	// referencing its source object, see ObjectRef.String.
+	MirrorSourceAnnotation = "controller-utils.ironcore.dev/mirror-source"
+	// MirrorSourceResourceVersionAnnotation is the annotation on every object created by a Mirror in the target
+	// cluster containing the resource version of the source object it was last mirrored from.
+	MirrorSourceResourceVersionAnnotation = "controller-utils.ironcore.dev/mirror-source-resource-version"
+)
+
+// MirrorTransformFunc transforms a source object before it is written to the target cluster,
+// e.g. to change its namespace or drop fields. It must not change the kind of the object.
+type MirrorTransformFunc func(obj *unstructured.Unstructured) error
+
+// ApplyToMirror implements MirrorOption.
+func (f MirrorTransformFunc) ApplyToMirror(o *MirrorOptions) {
+	o.Transform = f
+}
+
+// MirrorOptions are options for NewMirror.
+type MirrorOptions struct {
+	// Kinds are the kinds of objects to mirror. At least one kind is required.
+	Kinds []schema.GroupVersionKind
+	// Namespaces restricts mirroring namespaced objects to the given source namespaces.
+	// If empty, objects of all namespaces are mirrored.
+	Namespaces []string
+	// LabelSelector restricts mirroring to source objects matching the selector.
+	LabelSelector labels.Selector
+	// Transform transforms the source objects before they are written to the target cluster.
+	Transform MirrorTransformFunc
+}
+
+// ApplyToMirror implements MirrorOption.
+func (o *MirrorOptions) ApplyToMirror(o2 *MirrorOptions) {
+	o2.Kinds = append(o2.Kinds, o.Kinds...)
+	o2.Namespaces = append(o2.Namespaces, o.Namespaces...)
+	if o.LabelSelector != nil {
+		o2.LabelSelector = o.LabelSelector
+	}
+	if o.Transform != nil {
+		o2.Transform = o.Transform
+	}
+}
+
+// ApplyOptions applies all MirrorOption to this MirrorOptions.
+func (o *MirrorOptions) ApplyOptions(opts []MirrorOption) {
+	for _, opt := range opts {
+		opt.ApplyToMirror(o)
+	}
+}
+
+// MirrorOption is an option to NewMirror.
+type MirrorOption interface {
+	// ApplyToMirror modifies the underlying MirrorOptions.
+	ApplyToMirror(o *MirrorOptions)
+}
+
+// MirrorKinds adds to MirrorOptions.Kinds.
+type MirrorKinds []schema.GroupVersionKind
+
+// ApplyToMirror implements MirrorOption.
+func (k MirrorKinds) ApplyToMirror(o *MirrorOptions) {
+	o.Kinds = append(o.Kinds, k...)
+}
+
+// MirrorNamespaces adds to MirrorOptions.Namespaces.
+type MirrorNamespaces []string
+
+// ApplyToMirror implements MirrorOption.
+func (n MirrorNamespaces) ApplyToMirror(o *MirrorOptions) {
+	o.Namespaces = append(o.Namespaces, n...)
+}
+
+// MirrorLabelSelector sets MirrorOptions.LabelSelector.
+type MirrorLabelSelector struct {
+	Selector labels.Selector
+}
+
+// ApplyToMirror implements MirrorOption.
+func (s MirrorLabelSelector) ApplyToMirror(o *MirrorOptions) {
+	o.LabelSelector = s.Selector
+}
+
+// Mirror mirrors objects from a source cluster into a target cluster.
+//
+// Every mirrored object is labeled with MirrorLabel and annotated with its source (see MirrorSourceAnnotation).
+// Objects in the target cluster that exist but were not created by the Mirror are never modified.
+type Mirror struct {
+	source    client.Client
+	target    client.Client
+	name      string
+	kinds     []schema.GroupVersionKind
+	nss       []string
+	sel       labels.Selector
+	transform MirrorTransformFunc
+}
+
+// NewMirror creates a new Mirror with the given name that mirrors objects from the source into the target client.
+// The name distinguishes the objects of different Mirrors writing into the same target cluster.
+func NewMirror(source, target client.Client, name string, opts ...MirrorOption) (*Mirror, error) {
+	o := &MirrorOptions{}
+	o.ApplyOptions(opts)
+	if len(o.Kinds) == 0 {
+		return nil, fmt.Errorf("must specify at least one kind")
+	}
+
+	sel := o.LabelSelector
+	if sel == nil {
+		sel = labels.Everything()
+	}
+	return &Mirror{
+		source:    source,
+		target:    target,
+		name:      name,
+		kinds:     o.Kinds,
+		nss:       o.Namespaces,
+		sel:       sel,
+		transform: o.Transform,
+	}, nil
+}
+
+// MirrorResult is the result of a Mirror sync.
+type MirrorResult struct {
+	// Created references the objects created in the target cluster.
+	Created []ObjectRef
+	// Updated references the objects updated in the target cluster.
+	Updated []ObjectRef
+	// Deleted references the objects deleted from the target cluster.
+	Deleted []ObjectRef
+}
+
+func newMirrorObject(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj
+}
+
+func newMirrorList(gvk schema.GroupVersionKind) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	return list
+}
+
+// Sync makes the target cluster match the source cluster: it creates or updates the mirrored objects of all
+// matching source objects and deletes all mirrored objects whose source no longer exists or matches.
+func (m *Mirror) Sync(ctx context.Context) (*MirrorResult, error) {
+	res := &MirrorResult{}
+	for _, gvk := range m.kinds {
+		sources, err := m.listSources(ctx, gvk)
+		if err != nil {
+			return res, err
+		}
+
+		keep := NewObjectRefSet()
+		for i := range sources {
+			ref, err := m.mirror(ctx, &sources[i], res)
+			if err != nil {
+				return res, err
+			}
+			keep.Insert(ref)
+		}
+
+		mirrored, err := m.listMirrored(ctx, gvk)
+		if err != nil {
+			return res, err
+		}
+		for i := range mirrored {
+			if ref := (ObjectRef{GroupKind: gvk.GroupKind(), Key: client.ObjectKeyFromObject(&mirrored[i])}); !keep.Has(ref) {
+				if err := m.delete(ctx, &mirrored[i], res); err != nil {
+					return res, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// SyncRef mirrors the referenced source object, e.g. when reconciling source objects in a controller.
+// If the source object does not exist or does not match the Mirror, its mirrored objects are deleted.
+// The kind of the referenced object has to be one of the mirrored kinds.
+func (m *Mirror) SyncRef(ctx context.Context, ref ObjectRef) (*MirrorResult, error) {
+	idx := slices.IndexFunc(m.kinds, func(gvk schema.GroupVersionKind) bool {
+		return gvk.GroupKind() == ref.GroupKind
+	})
+	if idx < 0 {
+		return nil, fmt.Errorf("kind %s is not mirrored", ref.GroupKind)
+	}
+	gvk := m.kinds[idx]
+
+	res := &MirrorResult{}
+	source := newMirrorObject(gvk)
+	if err := m.source.Get(ctx, ref.Key, source); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("error getting source object %s: %w", ref, err)
+		}
+		source = nil
+	}
+
+	var keep ObjectRef
+	if source != nil && m.matches(source) {
+		var err error
+		if keep, err = m.mirror(ctx, source, res); err != nil {
+			return res, err
+		}
+	}
+
+	mirrored, err := m.listMirrored(ctx, gvk)
+	if err != nil {
+		return res, err
+	}
+	for i := range mirrored {
+		obj := &mirrored[i]
+		if obj.GetAnnotations()[MirrorSourceAnnotation] != ref.String() {
+			continue
+		}
+		if (ObjectRef{GroupKind: ref.GroupKind, Key: client.ObjectKeyFromObject(obj)}) == keep {
+			continue
+		}
+		if err := m.delete(ctx, obj, res); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func (m *Mirror) namespaced(gvk schema.GroupVersionKind) (bool, error) {
+	namespaced, err := apiutil.IsGVKNamespaced(gvk, m.source.RESTMapper())
+	if err != nil {
+		return false, fmt.Errorf("error determining whether %s is namespaced: %w", gvk, err)
+	}
+	return namespaced, nil
+}
+
+func (m *Mirror) matches(source *unstructured.Unstructured) bool {
+	if !m.sel.Matches(labels.Set(source.GetLabels())) {
+		return false
+	}
+	return len(m.nss) == 0 || source.GetNamespace() == "" || slices.Contains(m.nss, source.GetNamespace())
+}
+
+func (m *Mirror) listSources(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	namespaced, err := m.namespaced(gvk)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := m.nss
+	if !namespaced || len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var res []unstructured.Unstructured
+	for _, namespace := range namespaces {
+		list := newMirrorList(gvk)
+		if err := ListAll(ctx, m.source, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: m.sel}); err != nil {
+			return nil, fmt.Errorf("error listing source objects of kind %s: %w", gvk, err)
+		}
+		res = append(res, list.Items...)
+	}
+	return res, nil
+}
+
+func (m *Mirror) listMirrored(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	list := newMirrorList(gvk)
+	if err := ListAll(ctx, m.target, list, client.MatchingLabels{MirrorLabel: m.name}); err != nil {
+		return nil, fmt.Errorf("error listing mirrored objects of kind %s: %w", gvk, err)
+	}
+	return list.Items, nil
+}
+
+// desired returns the desired state of the mirrored object of the given source object.
+func (m *Mirror) desired(source *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	sourceRef := ObjectRef{GroupKind: source.GroupVersionKind().GroupKind(), Key: client.ObjectKeyFromObject(source)}
+	desired := source.DeepCopy()
+	stripServerFields(desired, false)
+	if m.transform != nil {
+		if err := m.transform(desired); err != nil {
+			return nil, fmt.Errorf("error transforming source object %s: %w", sourceRef, err)
+		}
+	}
+	if desired.GroupVersionKind().GroupKind() != sourceRef.GroupKind {
+		return nil, fmt.Errorf("transforming source object %s changed its kind to %s", sourceRef, desired.GroupVersionKind().GroupKind())
+	}
+
+	metautils.SetLabel(desired, MirrorLabel, m.name)
+	metautils.SetAnnotations(desired, map[string]string{
+		MirrorSourceAnnotation:                sourceRef.String(),
+		MirrorSourceResourceVersionAnnotation: source.GetResourceVersion(),
+	})
+	return desired, nil
+}
+
+// mirror creates or updates the mirrored object of the given source object and returns its reference.
+func (m *Mirror) mirror(ctx context.Context, source *unstructured.Unstructured, res *MirrorResult) (ObjectRef, error) {
+	desired, err := m.desired(source)
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	ref := ObjectRef{GroupKind: desired.GroupVersionKind().GroupKind(), Key: client.ObjectKeyFromObject(desired)}
+
+	existing := newMirrorObject(desired.GroupVersionKind())
+	if err := m.target.Get(ctx, ref.Key, existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ref, fmt.Errorf("error getting mirrored object %s: %w", ref, err)
+		}
+
+		if err := m.target.Create(ctx, desired); err != nil {
+			return ref, fmt.Errorf("error creating mirrored object %s: %w", ref, err)
+		}
+		res.Created = append(res.Created, ref)
+		return ref, nil
+	}
+
+	if existing.GetLabels()[MirrorLabel] != m.name {
+		return ref, fmt.Errorf("object %s already exists in the target cluster and is not managed by mirror %s", ref, m.name)
+	}
+
+	// Keep the server-set metadata of the existing object, so the patch only contains the mirrored changes.
+	updated := existing.DeepCopy()
+	for field, value := range desired.Object {
+		if field != "metadata" && field != "status" {
+			updated.Object[field] = value
+		}
+	}
+	for field := range existing.Object {
+		if _, ok := desired.Object[field]; !ok && field != "metadata" && field != "status" {
+			delete(updated.Object, field)
+		}
+	}
+	updated.SetLabels(desired.GetLabels())
+	updated.SetAnnotations(desired.GetAnnotations())
+
+	patch := client.MergeFrom(existing)
+	data, err := patch.Data(updated)
+	if err != nil {
+		return ref, fmt.Errorf("error computing patch for mirrored object %s: %w", ref, err)
+	}
+	if bytes.Equal(data, []byte("{}")) {
+		return ref, nil
+	}
+	if err := m.target.Patch(ctx, updated, patch); err != nil {
+		return ref, fmt.Errorf("error patching mirrored object %s: %w", ref, err)
+	}
+	res.Updated = append(res.Updated, ref)
+	return ref, nil
+}
+
+func (m *Mirror) delete(ctx context.Context, obj *unstructured.Unstructured, res *MirrorResult) error {
+	ref := ObjectRef{GroupKind: obj.GroupVersionKind().GroupKind(), Key: client.ObjectKeyFromObject(obj)}
+	if err := m.target.Delete(ctx, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("error deleting mirrored object %s: %w", ref, err)
+	}
+	res.Deleted = append(res.Deleted, ref)
+	return nil
+}
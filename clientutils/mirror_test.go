@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Mirror", func() {
+	var (
+		ctx          context.Context
+		src, dst     client.Client
+		mirror       *Mirror
+		cmGVK        = corev1.SchemeGroupVersion.WithKind("ConfigMap")
+		cmGK         = schema.GroupKind{Kind: "ConfigMap"}
+		withAppLabel = map[string]string{"app": "foo"}
+		toMirrorNS   = MirrorTransformFunc(func(obj *unstructured.Unstructured) error {
+			obj.SetNamespace("mirror")
+			return nil
+		})
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		newFakeClient := func(objs ...client.Object) client.Client {
+			return fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)).
+				WithObjects(objs...).
+				Build()
+		}
+		src = newFakeClient(
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "cm-a", Labels: withAppLabel},
+				Data:       map[string]string{"foo": "bar"},
+			},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "unlabeled"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-2", Name: "cm-b", Labels: withAppLabel}},
+		)
+		dst = newFakeClient(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "mirror", Name: "unmanaged"}})
+
+		var err error
+		mirror, err = NewMirror(src, dst, "test",
+			MirrorKinds{cmGVK},
+			MirrorNamespaces{"ns-1"},
+			MirrorLabelSelector{Selector: labels.SelectorFromSet(withAppLabel)},
+			toMirrorNS,
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	cmRef := func(namespace, name string) ObjectRef {
+		return ObjectRef{GroupKind: cmGK, Key: client.ObjectKey{Namespace: namespace, Name: name}}
+	}
+
+	It("should require at least one kind", func() {
+		_, err := NewMirror(src, dst, "test")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("Sync", func() {
+		It("should create the transformed matching objects with their provenance", func() {
+			res, err := mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Created).To(Equal([]ObjectRef{cmRef("mirror", "cm-a")}))
+			Expect(res.Updated).To(BeEmpty())
+			Expect(res.Deleted).To(BeEmpty())
+
+			cm := &corev1.ConfigMap{}
+			Expect(dst.Get(ctx, client.ObjectKey{Namespace: "mirror", Name: "cm-a"}, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(map[string]string{"foo": "bar"}))
+			Expect(cm.Labels).To(HaveKeyWithValue(MirrorLabel, "test"))
+			Expect(cm.Annotations).To(HaveKeyWithValue(MirrorSourceAnnotation, "ConfigMap/ns-1/cm-a"))
+			Expect(cm.Annotations).To(HaveKeyWithValue(MirrorSourceResourceVersionAnnotation, "999"))
+		})
+
+		It("should patch changed objects and leave unchanged objects untouched", func() {
+			_, err := mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			res, err := mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(&MirrorResult{}))
+
+			cm := &corev1.ConfigMap{}
+			Expect(src.Get(ctx, client.ObjectKey{Namespace: "ns-1", Name: "cm-a"}, cm)).To(Succeed())
+			cm.Data = map[string]string{"foo": "baz"}
+			Expect(src.Update(ctx, cm)).To(Succeed())
+
+			res, err = mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Updated).To(Equal([]ObjectRef{cmRef("mirror", "cm-a")}))
+
+			mirrored := &corev1.ConfigMap{}
+			Expect(dst.Get(ctx, client.ObjectKey{Namespace: "mirror", Name: "cm-a"}, mirrored)).To(Succeed())
+			Expect(mirrored.Data).To(Equal(map[string]string{"foo": "baz"}))
+			Expect(mirrored.Annotations).To(HaveKeyWithValue(MirrorSourceResourceVersionAnnotation, cm.ResourceVersion))
+		})
+
+		It("should delete mirrored objects whose source is gone and leave unmanaged objects alone", func() {
+			_, err := mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(src.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "cm-a"}})).To(Succeed())
+
+			res, err := mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Deleted).To(Equal([]ObjectRef{cmRef("mirror", "cm-a")}))
+
+			err = dst.Get(ctx, client.ObjectKey{Namespace: "mirror", Name: "cm-a"}, &corev1.ConfigMap{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(dst.Get(ctx, client.ObjectKey{Namespace: "mirror", Name: "unmanaged"}, &corev1.ConfigMap{})).To(Succeed())
+		})
+
+		It("should not overwrite objects not managed by the mirror", func() {
+			Expect(dst.Create(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "mirror", Name: "cm-a"}})).To(Succeed())
+
+			_, err := mirror.Sync(ctx)
+			Expect(err).To(MatchError(ContainSubstring("not managed by mirror test")))
+		})
+	})
+
+	Describe("SyncRef", func() {
+		It("should create the mirrored object if it does not exist in the target", func() {
+			res, err := mirror.SyncRef(ctx, cmRef("ns-1", "cm-a"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Created).To(Equal([]ObjectRef{cmRef("mirror", "cm-a")}))
+		})
+
+		It("should delete the mirrored object if the source does not exist", func() {
+			_, err := mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(src.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "cm-a"}})).To(Succeed())
+
+			res, err := mirror.SyncRef(ctx, cmRef("ns-1", "cm-a"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Deleted).To(Equal([]ObjectRef{cmRef("mirror", "cm-a")}))
+		})
+
+		It("should delete the mirrored object if the source no longer matches", func() {
+			_, err := mirror.Sync(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			cm := &corev1.ConfigMap{}
+			Expect(src.Get(ctx, client.ObjectKey{Namespace: "ns-1", Name: "cm-a"}, cm)).To(Succeed())
+			cm.Labels = nil
+			Expect(src.Update(ctx, cm)).To(Succeed())
+
+			res, err := mirror.SyncRef(ctx, cmRef("ns-1", "cm-a"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Deleted).To(Equal([]ObjectRef{cmRef("mirror", "cm-a")}))
+		})
+
+		It("should succeed if neither the source nor the mirrored object exist", func() {
+			res, err := mirror.SyncRef(ctx, cmRef("ns-1", "missing"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(&MirrorResult{}))
+		})
+
+		It("should error for kinds that are not mirrored", func() {
+			_, err := mirror.SyncRef(ctx, ObjectRef{GroupKind: schema.GroupKind{Kind: "Secret"}, Key: client.ObjectKey{Namespace: "ns-1", Name: "foo"}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
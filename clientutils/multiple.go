@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MultipleOptions are options for batch operations like CreateMultiple, PatchMultiple or DeleteMultiple.
+type MultipleOptions struct {
+	// KindOrder, if set, sorts the objects by their kind before processing them.
+	// Creating and patching happens in ascending, deleting in descending order.
+	KindOrder KindOrder
+	// MaxConcurrency is the maximum number of objects processed in parallel.
+	// Values lower than or equal to 1 result in sequential processing, which is the default.
+	// When ordering by kind, objects of different priorities are never processed in parallel.
+	MaxConcurrency int
+	// ContinueOnError makes the batch operation attempt all objects, even if processing some of them failed.
+	// The errors of all failed objects are reported with a *MultipleError.
+	ContinueOnError bool
+}
+
+// ApplyToMultiple implements MultipleOption.
+func (o *MultipleOptions) ApplyToMultiple(o2 *MultipleOptions) {
+	if o.KindOrder != nil {
+		o2.KindOrder = o.KindOrder
+	}
+	if o.MaxConcurrency > 0 {
+		o2.MaxConcurrency = o.MaxConcurrency
+	}
+	if o.ContinueOnError {
+		o2.ContinueOnError = true
+	}
+}
+
+// ApplyToCreate implements client.CreateOption. It is a no-op, see MultipleOption.
+func (o *MultipleOptions) ApplyToCreate(*client.CreateOptions) {}
+
+// ApplyToPatch implements client.PatchOption. It is a no-op, see MultipleOption.
+func (o *MultipleOptions) ApplyToPatch(*client.PatchOptions) {}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see MultipleOption.
+func (o *MultipleOptions) ApplyToDelete(*client.DeleteOptions) {}
+
+// ApplyOptions applies all MultipleOption to this MultipleOptions.
+func (o *MultipleOptions) ApplyOptions(opts []MultipleOption) {
+	for _, opt := range opts {
+		opt.ApplyToMultiple(o)
+	}
+}
+
+// MultipleOption is an option to a batch operation.
+//
+// To be usable alongside regular client options, implementations also implement client.CreateOption,
+// client.PatchOption and client.DeleteOption. The batch operations filter them out before calling
+// the client, so they have no effect when passed to a client.Client directly.
+type MultipleOption interface {
+	// ApplyToMultiple modifies the underlying MultipleOptions.
+	ApplyToMultiple(o *MultipleOptions)
+}
+
+// OrderByKind sorts the objects of a batch operation by their kind using Order.
+// If Order is nil, DefaultKindOrder is used.
+type OrderByKind struct {
+	Order KindOrder
+}
+
+// ApplyToMultiple implements MultipleOption.
+func (k OrderByKind) ApplyToMultiple(o *MultipleOptions) {
+	if k.Order != nil {
+		o.KindOrder = k.Order
+	} else {
+		o.KindOrder = DefaultKindOrder
+	}
+}
+
+// ApplyToCreate implements client.CreateOption. It is a no-op, see MultipleOption.
+func (k OrderByKind) ApplyToCreate(*client.CreateOptions) {}
+
+// ApplyToPatch implements client.PatchOption. It is a no-op, see MultipleOption.
+func (k OrderByKind) ApplyToPatch(*client.PatchOptions) {}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see MultipleOption.
+func (k OrderByKind) ApplyToDelete(*client.DeleteOptions) {}
+
+// MaxConcurrency processes up to the given number of objects of a batch operation in parallel.
+// Use MaxConcurrency(1) to explicitly request sequential processing.
+type MaxConcurrency int
+
+// ApplyToMultiple implements MultipleOption.
+func (m MaxConcurrency) ApplyToMultiple(o *MultipleOptions) {
+	o.MaxConcurrency = int(m)
+}
+
+// ApplyToCreate implements client.CreateOption. It is a no-op, see MultipleOption.
+func (m MaxConcurrency) ApplyToCreate(*client.CreateOptions) {}
+
+// ApplyToPatch implements client.PatchOption. It is a no-op, see MultipleOption.
+func (m MaxConcurrency) ApplyToPatch(*client.PatchOptions) {}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see MultipleOption.
+func (m MaxConcurrency) ApplyToDelete(*client.DeleteOptions) {}
+
+// ContinueOnError makes a batch operation attempt all objects, even if processing some of them failed.
+// If any object failed, a *MultipleError is returned.
+type ContinueOnError struct{}
+
+// ApplyToMultiple implements MultipleOption.
+func (ContinueOnError) ApplyToMultiple(o *MultipleOptions) {
+	o.ContinueOnError = true
+}
+
+// ApplyToCreate implements client.CreateOption. It is a no-op, see MultipleOption.
+func (ContinueOnError) ApplyToCreate(*client.CreateOptions) {}
+
+// ApplyToPatch implements client.PatchOption. It is a no-op, see MultipleOption.
+func (ContinueOnError) ApplyToPatch(*client.PatchOptions) {}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see MultipleOption.
+func (ContinueOnError) ApplyToDelete(*client.DeleteOptions) {}
+
+// splitMultipleOptions separates the MultipleOption values from the given client options.
+// The remaining options are returned in their original order.
+func splitMultipleOptions[O any](opts []O) (*MultipleOptions, []O) {
+	o := &MultipleOptions{}
+	var rest []O
+	for _, opt := range opts {
+		if mOpt, ok := any(opt).(MultipleOption); ok {
+			mOpt.ApplyToMultiple(o)
+			continue
+		}
+		rest = append(rest, opt)
+	}
+	return o, rest
+}
+
+// groupsFor returns the groups of indices in which the given objects should be processed according to the
+// MultipleOptions. Groups have to be processed one after another, while the indices within a group may be
+// processed in parallel. If reverse is true, the kind order is reversed.
+func (o *MultipleOptions) groupsFor(c clientMeta, objs []client.Object, reverse bool) ([][]int, error) {
+	if o.KindOrder == nil {
+		indices := make([]int, len(objs))
+		for i := range indices {
+			indices[i] = i
+		}
+		return [][]int{indices}, nil
+	}
+	return groupByKind(c, o.KindOrder, objs, reverse)
+}
+
+// batch is a batch operation on a list of objects.
+type batch struct {
+	// verb is the operation run on each object.
+	verb Verb
+	// objs are the objects to process.
+	objs []client.Object
+	// key optionally returns the key of the object with the given index.
+	// If unset, the key is obtained from the object itself.
+	key func(i int) client.ObjectKey
+	// reverse reverses the kind order, if any.
+	reverse bool
+	// do runs the operation for the object with the given index.
+	do func(ctx context.Context, i int) error
+	// wrap annotates the error of the object with the given index when aborting at the first error.
+	wrap func(i int, err error) error
+}
+
+func (b *batch) objectKey(i int) client.ObjectKey {
+	if b.key != nil {
+		return b.key(i)
+	}
+	return client.ObjectKeyFromObject(b.objs[i])
+}
+
+// run runs the batch operation.
+//
+// Groups of objects are processed one after another, while up to MaxConcurrency objects of a group are processed
+// in parallel. Without ContinueOnError, processing stops at the first error, which is returned. Otherwise, all objects
+// are attempted and a *MultipleError is returned if any of them failed. No new object is processed once ctx is done.
+func (o *MultipleOptions) run(ctx context.Context, c clientMeta, b batch) error {
+	groups, err := o.groupsFor(c, b.objs, b.reverse)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(b.objs))
+	for _, group := range groups {
+		if failed := o.runGroup(ctx, group, errs, b.do); failed >= 0 {
+			return b.wrap(failed, errs[failed])
+		}
+	}
+	if !o.ContinueOnError {
+		return nil
+	}
+	return newMultipleError(c, &b, errs)
+}
+
+// runGroup calls f for the given indices and records any error in errs.
+// Without ContinueOnError, it stops at the first error and returns its index. Otherwise, it always returns -1.
+func (o *MultipleOptions) runGroup(ctx context.Context, indices []int, errs []error, f func(ctx context.Context, i int) error) (failed int) {
+	failed = -1
+	if o.MaxConcurrency <= 1 {
+		for _, i := range indices {
+			err := ctx.Err()
+			if err == nil {
+				err = f(ctx, i)
+			}
+			if err != nil {
+				errs[i] = err
+				if !o.ContinueOnError {
+					return i
+				}
+			}
+		}
+		return failed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		next = make(chan int)
+	)
+	for range min(o.MaxConcurrency, len(indices)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				err := ctx.Err()
+				if err == nil {
+					err = f(ctx, i)
+				}
+				if err == nil {
+					continue
+				}
+
+				errs[i] = err
+				if !o.ContinueOnError {
+					once.Do(func() {
+						failed = i
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	for _, i := range indices {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return failed
+}
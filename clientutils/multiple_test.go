@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	mockclient "github.com/ironcore-dev/controller-utils/mock/controller-runtime/client"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Multiple", func() {
+	var (
+		ctx  context.Context
+		ctrl *gomock.Controller
+		c    *mockclient.MockClient
+
+		objs []client.Object
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		ctrl = gomock.NewController(GinkgoT())
+		c = mockclient.NewMockClient(ctrl)
+
+		objs = nil
+		for i := range 6 {
+			objs = append(objs, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: corev1.NamespaceDefault,
+					Name:      fmt.Sprintf("cm-%d", i),
+				},
+			})
+		}
+	})
+
+	Describe("MaxConcurrency", func() {
+		It("should process objects in parallel up to the given limit", func() {
+			var (
+				mu          sync.Mutex
+				inFlight    int
+				maxInFlight int
+				release     = make(chan struct{})
+				releaseOnce sync.Once
+			)
+			c.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+				mu.Lock()
+				inFlight++
+				maxInFlight = max(maxInFlight, inFlight)
+				if inFlight == 3 {
+					releaseOnce.Do(func() { close(release) })
+				}
+				mu.Unlock()
+
+				<-release
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return nil
+			}).Times(len(objs))
+
+			Expect(CreateMultiple(ctx, c, objs, MaxConcurrency(3))).To(Succeed())
+			Expect(maxInFlight).To(Equal(3))
+		})
+
+		It("should abort at the first error and report the failing object", func() {
+			someErr := fmt.Errorf("some error")
+			var calls atomic.Int32
+			c.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				calls.Add(1)
+				if key.Name == "cm-0" {
+					return someErr
+				}
+				<-ctx.Done()
+				return ctx.Err()
+			}).MinTimes(1)
+
+			err := GetMultiple(ctx, c, GetRequestsFromObjects(objs), MaxConcurrency(2))
+			Expect(errors.Is(err, someErr)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("default/cm-0")))
+			Expect(calls.Load()).To(BeNumerically("<=", 2))
+		})
+
+		It("should not process any object once the context is done", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			Expect(DeleteMultiple(ctx, c, objs, MaxConcurrency(2))).To(MatchError(context.Canceled))
+			Expect(DeleteMultiple(ctx, c, objs)).To(MatchError(context.Canceled))
+		})
+
+		It("should process objects sequentially when requested", func() {
+			var calls []client.Object
+			for _, obj := range objs {
+				c.EXPECT().Patch(ctx, obj, ApplyAll.PatchFor(obj)).Do(func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) {
+					calls = append(calls, obj)
+				})
+			}
+
+			Expect(PatchMultiple(ctx, c, PatchRequestsFromObjectsAndProvider(objs, ApplyAll), MaxConcurrency(1))).To(Succeed())
+			Expect(calls).To(Equal(objs))
+		})
+	})
+
+	Describe("ContinueOnError", func() {
+		var (
+			fc     client.Client
+			cmGK   schema.GroupKind
+			cmKey1 client.ObjectKey
+			cmKey3 client.ObjectKey
+		)
+		BeforeEach(func() {
+			fc = fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(objs[1].DeepCopyObject().(client.Object), objs[3].DeepCopyObject().(client.Object)).
+				Build()
+			cmGK = schema.GroupKind{Group: corev1.GroupName, Kind: "ConfigMap"}
+			cmKey1 = client.ObjectKeyFromObject(objs[1])
+			cmKey3 = client.ObjectKeyFromObject(objs[3])
+		})
+
+		It("should attempt all objects and report each failure", func() {
+			err := CreateMultiple(ctx, fc, objs, ContinueOnError{})
+
+			var multiErr *MultipleError
+			Expect(errors.As(err, &multiErr)).To(BeTrue())
+			Expect(multiErr.Succeeded).To(Equal([]int{0, 2, 4, 5}))
+			Expect(multiErr.Failed).To(HaveLen(2))
+			Expect(multiErr.Failed[0]).To(HaveField("Index", 1))
+			Expect(multiErr.Failed[0]).To(HaveField("Ref", ObjectRef{GroupKind: cmGK, Key: cmKey1}))
+			Expect(multiErr.Failed[0]).To(HaveField("Verb", VerbCreate))
+			Expect(multiErr.Failed[1]).To(HaveField("Ref", ObjectRef{GroupKind: cmGK, Key: cmKey3}))
+
+			Expect(apierrors.IsAlreadyExists(multiErr.Failed[0])).To(BeTrue())
+			Expect(errors.Is(err, multiErr.Failed[1].Err)).To(BeTrue())
+
+			var objErr *ObjectError
+			Expect(errors.As(err, &objErr)).To(BeTrue())
+			Expect(objErr.Index).To(Equal(1))
+
+			var agg utilerrors.Aggregate
+			Expect(errors.As(err, &agg)).To(BeTrue())
+			Expect(agg.Errors()).To(HaveLen(2))
+
+			cm := &corev1.ConfigMap{}
+			Expect(fc.Get(ctx, client.ObjectKeyFromObject(objs[5]), cm)).To(Succeed())
+		})
+
+		It("should attempt all objects in parallel", func() {
+			err := GetMultiple(ctx, fc, GetRequestsFromObjects(objs), ContinueOnError{}, MaxConcurrency(3))
+
+			var multiErr *MultipleError
+			Expect(errors.As(err, &multiErr)).To(BeTrue())
+			Expect(multiErr.Succeeded).To(Equal([]int{1, 3}))
+			Expect(multiErr.Failed).To(HaveLen(4))
+			for _, objErr := range multiErr.Failed {
+				Expect(apierrors.IsNotFound(objErr)).To(BeTrue())
+				Expect(objErr.Verb).To(Equal(VerbGet))
+			}
+		})
+
+		It("should report objects that were not attempted because the context was done", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			existed, err := DeleteMultipleIfExist(ctx, fc, objs, ContinueOnError{})
+			Expect(existed).To(BeEmpty())
+
+			var multiErr *MultipleError
+			Expect(errors.As(err, &multiErr)).To(BeTrue())
+			Expect(multiErr.Failed).To(HaveLen(len(objs)))
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+
+		It("should return no error if all objects succeed", func() {
+			existed, err := DeleteMultipleIfExist(ctx, fc, objs, ContinueOnError{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(existed).To(Equal([]client.Object{objs[1], objs[3]}))
+		})
+	})
+})
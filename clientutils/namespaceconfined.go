@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// NamespaceNotAllowedError is returned by a client created with NamespaceConfinedClient for operations
+// outside its allowed namespaces.
+type NamespaceNotAllowedError struct {
+	// Verb is the rejected operation.
+	Verb Verb
+	// GVK is the group version kind of the object of the operation.
+	GVK schema.GroupVersionKind
+	// Namespace is the namespace of the operation. It is empty for cluster-scoped operations and
+	// operations across all namespaces.
+	Namespace string
+	// ClusterScoped reports whether the operation was rejected because it writes a cluster-scoped object.
+	ClusterScoped bool
+}
+
+// Error implements error.
+func (e *NamespaceNotAllowedError) Error() string {
+	switch {
+	case e.ClusterScoped:
+		return fmt.Sprintf("%s of cluster-scoped %s is not allowed", e.Verb, e.GVK.Kind)
+	case e.Namespace == "":
+		return fmt.Sprintf("%s of %s across all namespaces is not allowed", e.Verb, e.GVK.Kind)
+	default:
+		return fmt.Sprintf("%s of %s in namespace %q is not allowed", e.Verb, e.GVK.Kind, e.Namespace)
+	}
+}
+
+type namespaceConfinedClient struct {
+	client.Client
+	namespaces sets.Set[string]
+}
+
+// NamespaceConfinedClient returns a client.Client that only allows operations on namespaced objects in
+// the given namespaces and rejects writes of cluster-scoped objects with a *NamespaceNotAllowedError.
+// Reads of cluster-scoped objects are allowed. Lists of namespaced objects have to specify an allowed
+// namespace using client.InNamespace.
+func NamespaceConfinedClient(c client.Client, namespaces ...string) client.Client {
+	return namespaceConfinedClient{c, sets.New(namespaces...)}
+}
+
+// check checks whether an operation on the given namespace is allowed. If namespaced is false, the operation
+// is on a cluster-scoped object and is only allowed if it does not write.
+func (c namespaceConfinedClient) check(verb Verb, gvk schema.GroupVersionKind, namespaced bool, namespace string) error {
+	if !namespaced {
+		if isWriteVerb(verb) {
+			return &NamespaceNotAllowedError{Verb: verb, GVK: gvk, ClusterScoped: true}
+		}
+		return nil
+	}
+	if !c.namespaces.Has(namespace) {
+		return &NamespaceNotAllowedError{Verb: verb, GVK: gvk, Namespace: namespace}
+	}
+	return nil
+}
+
+func (c namespaceConfinedClient) checkObject(verb Verb, obj client.Object, namespace string) error {
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return fmt.Errorf("error getting gvk of object: %w", err)
+	}
+	namespaced, err := c.IsObjectNamespaced(obj)
+	if err != nil {
+		return fmt.Errorf("error determining whether %s is namespaced: %w", gvk.Kind, err)
+	}
+	return c.check(verb, gvk, namespaced, namespace)
+}
+
+func (c namespaceConfinedClient) checkList(verb Verb, list client.ObjectList, namespace string) error {
+	gvk := listItemGVK(c, list)
+	if gvk.Empty() {
+		return fmt.Errorf("error getting gvk of list items of %T", list)
+	}
+	namespaced, err := apiutil.IsGVKNamespaced(gvk, c.RESTMapper())
+	if err != nil {
+		return fmt.Errorf("error determining whether %s is namespaced: %w", gvk.Kind, err)
+	}
+	return c.check(verb, gvk, namespaced, namespace)
+}
+
+func (c namespaceConfinedClient) checkApply(obj runtime.ApplyConfiguration) error {
+	u := applyConfigurationObject(obj)
+	if u == nil {
+		return fmt.Errorf("error converting apply configuration %T to unstructured", obj)
+	}
+	return c.checkObject(VerbApply, u, u.GetNamespace())
+}
+
+func (c namespaceConfinedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.checkObject(VerbGet, obj, key.Namespace); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c namespaceConfinedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	o := &client.ListOptions{}
+	o.ApplyOptions(opts)
+	if err := c.checkList(VerbList, list, o.Namespace); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c namespaceConfinedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.checkObject(VerbCreate, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c namespaceConfinedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.checkObject(VerbUpdate, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c namespaceConfinedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.checkObject(VerbPatch, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c namespaceConfinedClient) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	if err := c.checkApply(obj); err != nil {
+		return err
+	}
+	return c.Client.Apply(ctx, obj, opts...)
+}
+
+func (c namespaceConfinedClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.checkObject(VerbDelete, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c namespaceConfinedClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	o := &client.DeleteAllOfOptions{}
+	o.ApplyOptions(opts)
+	if err := c.checkObject(VerbDeleteAllOf, obj, o.Namespace); err != nil {
+		return err
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c namespaceConfinedClient) Status() client.SubResourceWriter {
+	return namespaceConfinedSubResourceWriter{c.Client.Status(), c}
+}
+
+func (c namespaceConfinedClient) SubResource(subResource string) client.SubResourceClient {
+	sc := c.Client.SubResource(subResource)
+	return namespaceConfinedSubResourceClient{sc, namespaceConfinedSubResourceWriter{sc, c}}
+}
+
+type namespaceConfinedSubResourceWriter struct {
+	client.SubResourceWriter
+	c namespaceConfinedClient
+}
+
+func (w namespaceConfinedSubResourceWriter) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	if err := w.c.checkObject(VerbCreate, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Create(ctx, obj, subResource, opts...)
+}
+
+func (w namespaceConfinedSubResourceWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if err := w.c.checkObject(VerbUpdate, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+func (w namespaceConfinedSubResourceWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if err := w.c.checkObject(VerbPatch, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func (w namespaceConfinedSubResourceWriter) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
+	if err := w.c.checkApply(obj); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Apply(ctx, obj, opts...)
+}
+
+type namespaceConfinedSubResourceClient struct {
+	reader client.SubResourceReader
+	namespaceConfinedSubResourceWriter
+}
+
+func (c namespaceConfinedSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	if err := c.c.checkObject(VerbGet, obj, obj.GetNamespace()); err != nil {
+		return err
+	}
+	return c.reader.Get(ctx, obj, subResource, opts...)
+}
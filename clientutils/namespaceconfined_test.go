@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("NamespaceConfinedClient", func() {
+	var (
+		ctx     context.Context
+		fakeC   client.Client
+		c       client.Client
+		allowed *corev1.ConfigMap
+		denied  *corev1.ConfigMap
+		ns      *corev1.Namespace
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		allowed = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "allowed", Name: "my-cm"}}
+		denied = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "denied", Name: "my-cm"}}
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "allowed"}}
+		fakeC = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)).
+			WithObjects(allowed.DeepCopy(), denied.DeepCopy(), ns.DeepCopy()).
+			Build()
+		c = NamespaceConfinedClient(fakeC, "allowed")
+	})
+
+	expectNotAllowed := func(err error) *NamespaceNotAllowedError {
+		GinkgoHelper()
+		var notAllowedErr *NamespaceNotAllowedError
+		Expect(errors.As(err, &notAllowedErr)).To(BeTrue(), "expected a *NamespaceNotAllowedError but got %v", err)
+		return notAllowedErr
+	}
+
+	It("should allow operations in the allowed namespaces", func() {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(allowed), allowed)).To(Succeed())
+		Expect(c.List(ctx, &corev1.ConfigMapList{}, client.InNamespace("allowed"))).To(Succeed())
+		Expect(c.Update(ctx, allowed)).To(Succeed())
+		Expect(c.Delete(ctx, allowed)).To(Succeed())
+	})
+
+	It("should reject operations in other namespaces", func() {
+		err := expectNotAllowed(c.Get(ctx, client.ObjectKeyFromObject(denied), &corev1.ConfigMap{}))
+		Expect(err.Verb).To(Equal(VerbGet))
+		Expect(err.Namespace).To(Equal("denied"))
+		Expect(err.Error()).To(Equal(`get of ConfigMap in namespace "denied" is not allowed`))
+
+		expectNotAllowed(c.Delete(ctx, denied))
+		expectNotAllowed(c.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace("denied")))
+		expectNotAllowed(c.Status().Update(ctx, denied))
+	})
+
+	It("should reject lists across all namespaces", func() {
+		err := expectNotAllowed(c.List(ctx, &corev1.ConfigMapList{}))
+		Expect(err.Error()).To(Equal("list of ConfigMap across all namespaces is not allowed"))
+	})
+
+	It("should allow reading but reject writing cluster-scoped objects", func() {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(ns), ns)).To(Succeed())
+		Expect(c.List(ctx, &corev1.NamespaceList{})).To(Succeed())
+
+		err := expectNotAllowed(c.Update(ctx, ns))
+		Expect(err.ClusterScoped).To(BeTrue())
+		Expect(err.Error()).To(Equal("update of cluster-scoped Namespace is not allowed"))
+	})
+
+	It("should be stackable with ReaderClient and DryRunClient", func() {
+		c = NamespaceConfinedClient(DryRunClient(ReaderClient(fakeC, fakeC)), "allowed")
+
+		expectNotAllowed(c.Delete(ctx, denied))
+		Expect(c.Delete(ctx, allowed)).To(Succeed())
+		Expect(fakeC.Get(ctx, client.ObjectKeyFromObject(allowed), &corev1.ConfigMap{})).To(Succeed())
+	})
+})
@@ -3,7 +3,22 @@
 
 package clientutils
 
-import "sigs.k8s.io/controller-runtime/pkg/client"
+import (
+	"cmp"
+	"iter"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CompareObjectKeys compares the given keys by namespace and then by name.
+// It returns -1, 0 or +1, as cmp.Compare does, and can be used with slices.SortFunc.
+func CompareObjectKeys(a, b client.ObjectKey) int {
+	if c := cmp.Compare(a.Namespace, b.Namespace); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Name, b.Name)
+}
 
 // ObjectKeySet set is a set of client.ObjectKey.
 type ObjectKeySet map[client.ObjectKey]struct{}
@@ -35,6 +50,36 @@ func (s ObjectKeySet) Len() int {
 	return len(s)
 }
 
+// Union returns a new ObjectKeySet containing the items of both s and s2.
+func (s ObjectKeySet) Union(s2 ObjectKeySet) ObjectKeySet {
+	return setUnion(s, s2)
+}
+
+// Intersection returns a new ObjectKeySet containing the items present in both s and s2.
+func (s ObjectKeySet) Intersection(s2 ObjectKeySet) ObjectKeySet {
+	return setIntersection(s, s2)
+}
+
+// Difference returns a new ObjectKeySet containing the items of s that are not present in s2.
+func (s ObjectKeySet) Difference(s2 ObjectKeySet) ObjectKeySet {
+	return setDifference(s, s2)
+}
+
+// Equal reports whether s and s2 contain the same items.
+func (s ObjectKeySet) Equal(s2 ObjectKeySet) bool {
+	return setEqual(s, s2)
+}
+
+// List returns the items of the ObjectKeySet sorted by CompareObjectKeys.
+func (s ObjectKeySet) List() []client.ObjectKey {
+	return setSortedKeys(s, CompareObjectKeys)
+}
+
+// All returns an iterator over the items of the ObjectKeySet sorted by CompareObjectKeys.
+func (s ObjectKeySet) All() iter.Seq[client.ObjectKey] {
+	return slices.Values(s.List())
+}
+
 // NewObjectKeySet creates a new ObjectKeySet and initializes it with the given items.
 func NewObjectKeySet(items ...client.ObjectKey) ObjectKeySet {
 	s := make(ObjectKeySet)
@@ -110,5 +110,52 @@ var _ = Describe("ObjectKey", func() {
 				Expect(NewObjectKeySet().Len()).To(Equal(0))
 			})
 		})
+
+		Describe("Union", func() {
+			It("should return a new set with the items of both sets", func() {
+				s1 := NewObjectKeySet(k1, k2)
+				s2 := NewObjectKeySet(k2, k3)
+				Expect(s1.Union(s2)).To(Equal(NewObjectKeySet(k1, k2, k3)))
+				Expect(s1).To(Equal(NewObjectKeySet(k1, k2)))
+			})
+		})
+
+		Describe("Intersection", func() {
+			It("should return a new set with the items present in both sets", func() {
+				Expect(NewObjectKeySet(k1, k2).Intersection(NewObjectKeySet(k2, k3))).To(Equal(NewObjectKeySet(k2)))
+			})
+		})
+
+		Describe("Difference", func() {
+			It("should return a new set with the items not present in the other set", func() {
+				Expect(NewObjectKeySet(k1, k2).Difference(NewObjectKeySet(k2, k3))).To(Equal(NewObjectKeySet(k1)))
+			})
+		})
+
+		Describe("Equal", func() {
+			It("should report whether both sets contain the same items", func() {
+				Expect(NewObjectKeySet(k1, k2).Equal(NewObjectKeySet(k2, k1))).To(BeTrue())
+				Expect(NewObjectKeySet(k1, k2).Equal(NewObjectKeySet(k1))).To(BeFalse())
+				Expect(NewObjectKeySet(k1).Equal(NewObjectKeySet(k2))).To(BeFalse())
+				Expect(NewObjectKeySet().Equal(nil)).To(BeTrue())
+			})
+		})
+
+		Describe("List", func() {
+			It("should return the items sorted by namespace and name", func() {
+				s := NewObjectKeySet(k1, k2, k3, k4, k5, k6)
+				Expect(s.List()).To(Equal([]client.ObjectKey{k5, k6, k2, k1, k4, k3}))
+			})
+		})
+
+		Describe("All", func() {
+			It("should iterate over the items in sorted order", func() {
+				var items []client.ObjectKey
+				for key := range NewObjectKeySet(k1, k2, k3).All() {
+					items = append(items, key)
+				}
+				Expect(items).To(Equal([]client.ObjectKey{k2, k1, k3}))
+			})
+		})
 	})
 })
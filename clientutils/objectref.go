@@ -4,6 +4,12 @@
 package clientutils
 
 import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -16,6 +22,105 @@ type ObjectRef struct {
 	Key       client.ObjectKey
 }
 
+// String returns the canonical string representation of the ObjectRef.
+//
+// The format is <kind>[.<group>][/<namespace>]/<name>, e.g. 'ConfigMap/default/my-cm' for a
+// namespaced object of the core group or 'ClusterRole.rbac.authorization.k8s.io/my-role' for a
+// cluster-scoped object. The zero ObjectRef is represented as the empty string.
+// Use ParseObjectRef to parse the result.
+//
+// The kind is qualified with its group the way schema.GroupKind.String and kubectl do (e.g. 'deployment.apps/foo')
+// instead of using a <group>/<kind>/<namespace>/<name> form: this way, references to objects of the core group and
+// to cluster-scoped objects do not need empty path segments, and the number of segments alone tells whether a
+// reference is namespaced.
+func (r ObjectRef) String() string {
+	if r == (ObjectRef{}) {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(r.GroupKind.String())
+	if r.Key.Namespace != "" {
+		sb.WriteString("/")
+		sb.WriteString(r.Key.Namespace)
+	}
+	sb.WriteString("/")
+	sb.WriteString(r.Key.Name)
+	return sb.String()
+}
+
+// ParseObjectRef parses the given string in the format of ObjectRef.String.
+func ParseObjectRef(s string) (ObjectRef, error) {
+	parts := strings.Split(s, "/")
+	var ref ObjectRef
+	switch len(parts) {
+	case 2:
+		ref = ObjectRef{GroupKind: schema.ParseGroupKind(parts[0]), Key: client.ObjectKey{Name: parts[1]}}
+	case 3:
+		if parts[1] == "" {
+			return ObjectRef{}, fmt.Errorf("invalid object ref %q: empty namespace", s)
+		}
+		ref = ObjectRef{GroupKind: schema.ParseGroupKind(parts[0]), Key: client.ObjectKey{Namespace: parts[1], Name: parts[2]}}
+	default:
+		return ObjectRef{}, fmt.Errorf("invalid object ref %q: expected <kind>[.<group>][/<namespace>]/<name>", s)
+	}
+	if ref.GroupKind.Kind == "" {
+		return ObjectRef{}, fmt.Errorf("invalid object ref %q: empty kind", s)
+	}
+	if ref.Key.Name == "" {
+		return ObjectRef{}, fmt.Errorf("invalid object ref %q: empty name", s)
+	}
+	return ref, nil
+}
+
+// MarshalText implements encoding.TextMarshaler using ObjectRef.String.
+// As a consequence, an ObjectRef is marshalled to a JSON string and can be used as JSON map key.
+// Non-zero references that cannot be parsed back by UnmarshalText, e.g. ones with an empty kind or name,
+// are refused with the error UnmarshalText would return.
+func (r ObjectRef) MarshalText() ([]byte, error) {
+	s := r.String()
+	if s == "" {
+		return []byte(s), nil
+	}
+
+	parsed, err := ParseObjectRef(s)
+	if err != nil {
+		return nil, err
+	}
+	if parsed != r {
+		return nil, fmt.Errorf("invalid object ref %q: does not match %#v", s, r)
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseObjectRef.
+// The empty text is unmarshalled to the zero ObjectRef.
+func (r *ObjectRef) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = ObjectRef{}
+		return nil
+	}
+
+	ref, err := ParseObjectRef(string(text))
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
+
+// CompareObjectRefs compares the given references by group, kind and then by key (see CompareObjectKeys).
+// It returns -1, 0 or +1, as cmp.Compare does, and can be used with slices.SortFunc.
+func CompareObjectRefs(a, b ObjectRef) int {
+	if c := cmp.Compare(a.GroupKind.Group, b.GroupKind.Group); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.GroupKind.Kind, b.GroupKind.Kind); c != 0 {
+		return c
+	}
+	return CompareObjectKeys(a.Key, b.Key)
+}
+
 // ObjectRefFromObject creates a new ObjectRef from the given client.Object.
 func ObjectRefFromObject(scheme *runtime.Scheme, obj client.Object) (ObjectRef, error) {
 	gvk, err := apiutil.GVKForObject(obj, scheme)
@@ -98,6 +203,36 @@ func (s ObjectRefSet) Len() int {
 	return len(s)
 }
 
+// Union returns a new set containing the items of both s and s2.
+func (s ObjectRefSet) Union(s2 ObjectRefSet) ObjectRefSet {
+	return setUnion(s, s2)
+}
+
+// Intersection returns a new set containing the items present in both s and s2.
+func (s ObjectRefSet) Intersection(s2 ObjectRefSet) ObjectRefSet {
+	return setIntersection(s, s2)
+}
+
+// Difference returns a new set containing the items of s that are not present in s2.
+func (s ObjectRefSet) Difference(s2 ObjectRefSet) ObjectRefSet {
+	return setDifference(s, s2)
+}
+
+// Equal reports whether s and s2 contain the same items.
+func (s ObjectRefSet) Equal(s2 ObjectRefSet) bool {
+	return setEqual(s, s2)
+}
+
+// List returns the items of the set sorted by CompareObjectRefs.
+func (s ObjectRefSet) List() []ObjectRef {
+	return setSortedKeys(s, CompareObjectRefs)
+}
+
+// All returns an iterator over the items of the set sorted by CompareObjectRefs.
+func (s ObjectRefSet) All() iter.Seq[ObjectRef] {
+	return slices.Values(s.List())
+}
+
 // NewObjectRefSet creates a new ObjectRefSet with the given set.
 func NewObjectRefSet(items ...ObjectRef) ObjectRefSet {
 	s := make(ObjectRefSet)
@@ -4,6 +4,8 @@
 package clientutils
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
@@ -121,6 +123,92 @@ var _ = Describe("ObjectRef", func() {
 		})
 	})
 
+	Describe("String", func() {
+		It("should format namespaced and cluster-scoped objects", func() {
+			Expect(cmRef.String()).To(Equal("ConfigMap/default/my-cm"))
+			Expect(ObjectRef{
+				GroupKind: schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+				Key:       client.ObjectKey{Name: "my-role"},
+			}.String()).To(Equal("ClusterRole.rbac.authorization.k8s.io/my-role"))
+			Expect(ObjectRef{}.String()).To(BeEmpty())
+		})
+	})
+
+	Describe("ParseObjectRef", func() {
+		It("should parse the output of String", func() {
+			deployRef := ObjectRef{
+				GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+				Key:       client.ObjectKey{Namespace: "default", Name: "my-deploy"},
+			}
+			nodeRef := ObjectRef{
+				GroupKind: schema.GroupKind{Kind: "Node"},
+				Key:       client.ObjectKey{Name: "my-node"},
+			}
+			for _, ref := range []ObjectRef{cmRef, deployRef, nodeRef} {
+				Expect(ParseObjectRef(ref.String())).To(Equal(ref))
+			}
+		})
+
+		It("should reject invalid object refs", func() {
+			for _, s := range []string{"", "ConfigMap", "ConfigMap//my-cm", "/default/my-cm", "ConfigMap/default/", "a/b/c/d"} {
+				_, err := ParseObjectRef(s)
+				Expect(err).To(HaveOccurred(), "expected %q to be invalid", s)
+			}
+		})
+	})
+
+	Describe("JSON marshalling", func() {
+		It("should round-trip as JSON string and map key", func() {
+			type status struct {
+				Ref  ObjectRef            `json:"ref"`
+				Refs map[ObjectRef]string `json:"refs"`
+			}
+			in := status{Ref: cmRef, Refs: map[ObjectRef]string{podRef: "foo"}}
+
+			data, err := json.Marshal(in)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"ref":"ConfigMap/default/my-cm","refs":{"Pod/default/my-pod":"foo"}}`))
+
+			var out status
+			Expect(json.Unmarshal(data, &out)).To(Succeed())
+			Expect(out).To(Equal(in))
+		})
+
+		It("should unmarshal the empty string to the zero object ref", func() {
+			var ref ObjectRef
+			Expect(json.Unmarshal([]byte(`""`), &ref)).To(Succeed())
+			Expect(ref).To(Equal(ObjectRef{}))
+			Expect(json.Unmarshal([]byte(`"ConfigMap"`), &ref)).NotTo(Succeed())
+		})
+
+		It("should round-trip the zero object ref", func() {
+			data, err := json.Marshal(ObjectRef{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`""`))
+
+			ref := cmRef
+			Expect(json.Unmarshal(data, &ref)).To(Succeed())
+			Expect(ref).To(Equal(ObjectRef{}))
+		})
+
+		It("should refuse to marshal object refs that cannot be unmarshalled", func() {
+			for _, ref := range []ObjectRef{
+				{Key: client.ObjectKey{Namespace: "default", Name: "my-cm"}},
+				{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Key: client.ObjectKey{Namespace: "default"}},
+				{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Key: client.ObjectKey{Name: "ns/my-cm"}},
+			} {
+				_, marshalErr := json.Marshal(ref)
+				Expect(marshalErr).To(HaveOccurred(), "marshalling %#v", ref)
+
+				var out ObjectRef
+				unmarshalErr := out.UnmarshalText([]byte(ref.String()))
+				if unmarshalErr != nil {
+					Expect(marshalErr).To(MatchError(ContainSubstring(unmarshalErr.Error())))
+				}
+			}
+		})
+	})
+
 	Context("ObjectRefSet", func() {
 		Describe("NewObjectRefSet", func() {
 			It("should create a new object ref set with the given items", func() {
@@ -172,6 +260,48 @@ var _ = Describe("ObjectRef", func() {
 			})
 		})
 
+		Describe("Union", func() {
+			It("should return a new set with the items of both sets", func() {
+				Expect(NewObjectRefSet(cmRef).Union(NewObjectRefSet(podRef))).To(Equal(NewObjectRefSet(cmRef, podRef)))
+			})
+		})
+
+		Describe("Intersection", func() {
+			It("should return a new set with the items present in both sets", func() {
+				Expect(NewObjectRefSet(cmRef, podRef).Intersection(NewObjectRefSet(podRef))).To(Equal(NewObjectRefSet(podRef)))
+			})
+		})
+
+		Describe("Difference", func() {
+			It("should return a new set with the items not present in the other set", func() {
+				desired := NewObjectRefSet(cmRef, podRef)
+				actual := NewObjectRefSet(cmRef)
+				Expect(desired.Difference(actual)).To(Equal(NewObjectRefSet(podRef)))
+				Expect(actual.Difference(desired)).To(Equal(NewObjectRefSet()))
+			})
+		})
+
+		Describe("Equal", func() {
+			It("should report whether both sets contain the same items", func() {
+				Expect(NewObjectRefSet(cmRef, podRef).Equal(NewObjectRefSet(podRef, cmRef))).To(BeTrue())
+				Expect(NewObjectRefSet(cmRef).Equal(NewObjectRefSet(podRef))).To(BeFalse())
+			})
+		})
+
+		Describe("List", func() {
+			It("should return the items sorted by group, kind and key", func() {
+				deployRef := ObjectRef{GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"}, Key: client.ObjectKey{Name: "a"}}
+				s := NewObjectRefSet(podRef, deployRef, cmRef)
+				Expect(s.List()).To(Equal([]ObjectRef{cmRef, podRef, deployRef}))
+
+				var items []ObjectRef
+				for ref := range s.All() {
+					items = append(items, ref)
+				}
+				Expect(items).To(Equal(s.List()))
+			})
+		})
+
 		Describe("ObjectRefSetReferencesObject", func() {
 			It("should report whether the object is referenced by the set", func() {
 				s := NewObjectRefSet(cmRef)
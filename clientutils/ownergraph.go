@@ -0,0 +1,401 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// OwnerGraphOptions are options for ListDependents and DependentTree.
+type OwnerGraphOptions struct {
+	// Kinds are the kinds to search for dependents.
+	// If empty, all listable kinds served by the API server are searched in their preferred version, as reported
+	// by Discovery. Kinds that cannot be listed, e.g. due to missing permissions, are skipped.
+	Kinds []schema.GroupVersionKind
+
+	// Discovery is used to determine the kinds to search if no Kinds are specified.
+	// If nil, all kinds of the client's scheme that are known to its RESTMapper are searched instead.
+	Discovery discovery.DiscoveryInterface
+}
+
+// ApplyToOwnerGraph implements OwnerGraphOption.
+func (o *OwnerGraphOptions) ApplyToOwnerGraph(o2 *OwnerGraphOptions) {
+	if len(o.Kinds) > 0 {
+		o2.Kinds = o.Kinds
+	}
+	if o.Discovery != nil {
+		o2.Discovery = o.Discovery
+	}
+}
+
+// ApplyOptions applies all OwnerGraphOption to this OwnerGraphOptions.
+func (o *OwnerGraphOptions) ApplyOptions(opts []OwnerGraphOption) {
+	for _, opt := range opts {
+		opt.ApplyToOwnerGraph(o)
+	}
+}
+
+// OwnerGraphOption is an option to ListDependents and DependentTree.
+type OwnerGraphOption interface {
+	// ApplyToOwnerGraph modifies the underlying OwnerGraphOptions.
+	ApplyToOwnerGraph(o *OwnerGraphOptions)
+}
+
+// DependentKinds sets OwnerGraphOptions.Kinds.
+type DependentKinds []schema.GroupVersionKind
+
+// ApplyToOwnerGraph implements OwnerGraphOption.
+func (k DependentKinds) ApplyToOwnerGraph(o *OwnerGraphOptions) {
+	o.Kinds = k
+}
+
+// DiscoverDependentKinds sets OwnerGraphOptions.Discovery.
+type DiscoverDependentKinds struct {
+	Discovery discovery.DiscoveryInterface
+}
+
+// ApplyToOwnerGraph implements OwnerGraphOption.
+func (d DiscoverDependentKinds) ApplyToOwnerGraph(o *OwnerGraphOptions) {
+	o.Discovery = d.Discovery
+}
+
+// OwnerNode is a node in a tree of objects linked by owner references.
+type OwnerNode struct {
+	// Ref references the object of the node.
+	Ref ObjectRef
+	// Object is the metadata of the object of the node.
+	Object *metav1.PartialObjectMetadata
+	// Controller reports whether the owner reference of the object to its parent node is a controller reference.
+	Controller bool
+	// Dependents are the nodes of the objects owned by this node's object, sorted by their Ref.
+	Dependents []*OwnerNode
+}
+
+// String prints the tree rooted at this node, one object per line.
+func (n *OwnerNode) String() string {
+	var sb strings.Builder
+	sb.WriteString(n.label())
+	sb.WriteString("\n")
+	n.writeDependents(&sb, "")
+	return sb.String()
+}
+
+func (n *OwnerNode) label() string {
+	if n.Controller {
+		return n.Ref.String() + " (controller)"
+	}
+	return n.Ref.String()
+}
+
+func (n *OwnerNode) writeDependents(sb *strings.Builder, prefix string) {
+	for i, dep := range n.Dependents {
+		branch, indent := "├── ", "│   "
+		if i == len(n.Dependents)-1 {
+			branch, indent = "└── ", "    "
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(branch)
+		sb.WriteString(dep.label())
+		sb.WriteString("\n")
+		dep.writeDependents(sb, prefix+indent)
+	}
+}
+
+// ListDependents lists the metadata of all objects that have an owner reference to the given owner, sorted by
+// their ObjectRef. See OwnerGraphOptions for the kinds that are searched.
+func ListDependents(ctx context.Context, c client.Client, owner client.Object, opts ...OwnerGraphOption) ([]*metav1.PartialObjectMetadata, error) {
+	o := &OwnerGraphOptions{}
+	o.ApplyOptions(opts)
+
+	index, err := o.dependentIndex(ctx, c, owner.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	return index[owner.GetUID()], nil
+}
+
+// DependentTree builds the tree of all objects transitively owned by the given owner, which is the root of
+// the tree. See OwnerGraphOptions for the kinds that are searched.
+func DependentTree(ctx context.Context, c client.Client, owner client.Object, opts ...OwnerGraphOption) (*OwnerNode, error) {
+	o := &OwnerGraphOptions{}
+	o.ApplyOptions(opts)
+
+	gvk, err := c.GroupVersionKindFor(owner)
+	if err != nil {
+		return nil, fmt.Errorf("error getting group version kind of owner: %w", err)
+	}
+
+	index, err := o.dependentIndex(ctx, c, owner.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	root := &OwnerNode{
+		Ref:    ObjectRef{GroupKind: gvk.GroupKind(), Key: client.ObjectKeyFromObject(owner)},
+		Object: meta.AsPartialObjectMetadata(owner),
+	}
+	root.Object.SetGroupVersionKind(gvk)
+	visited := map[types.UID]bool{owner.GetUID(): true}
+	var addDependents func(node *OwnerNode)
+	addDependents = func(node *OwnerNode) {
+		for _, dep := range index[node.Object.UID] {
+			if visited[dep.UID] {
+				continue
+			}
+			visited[dep.UID] = true
+
+			ownerRef := ownerReferenceTo(dep, node.Object.UID)
+			child := &OwnerNode{
+				Ref:        ObjectRef{GroupKind: dep.GroupVersionKind().GroupKind(), Key: client.ObjectKeyFromObject(dep)},
+				Object:     dep,
+				Controller: ownerRef.Controller != nil && *ownerRef.Controller,
+			}
+			node.Dependents = append(node.Dependents, child)
+			addDependents(child)
+		}
+	}
+	addDependents(root)
+	return root, nil
+}
+
+// ListAncestors walks up the owner references of the given object and returns the metadata of all its
+// transitive owners, nearest owners first.
+// Owner references to objects that do not exist (anymore) or whose kind is unknown are skipped.
+func ListAncestors(ctx context.Context, c client.Client, obj client.Object) ([]*metav1.PartialObjectMetadata, error) {
+	var (
+		res     []*metav1.PartialObjectMetadata
+		visited = map[types.UID]bool{obj.GetUID(): true}
+		queue   = []client.Object{obj}
+	)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for _, ownerRef := range cur.GetOwnerReferences() {
+			if visited[ownerRef.UID] {
+				continue
+			}
+			visited[ownerRef.UID] = true
+
+			owner, err := getOwner(ctx, c, cur.GetNamespace(), ownerRef)
+			if err != nil {
+				return nil, err
+			}
+			if owner == nil {
+				continue
+			}
+
+			res = append(res, owner)
+			queue = append(queue, owner)
+		}
+	}
+	return res, nil
+}
+
+// getOwner gets the metadata of the object referenced by the given owner reference of an object in namespace.
+// It returns nil if the owner does not exist, its kind is unknown or its UID does not match.
+func getOwner(ctx context.Context, c client.Client, namespace string, ownerRef metav1.OwnerReference) (*metav1.PartialObjectMetadata, error) {
+	gv, err := schema.ParseGroupVersion(ownerRef.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing api version of owner %s: %w", ownerRef.Name, err)
+	}
+	gvk := gv.WithKind(ownerRef.Kind)
+
+	namespaced, err := apiutil.IsGVKNamespaced(gvk, c.RESTMapper())
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error determining scope of %s: %w", gvk, err)
+	}
+
+	key := client.ObjectKey{Name: ownerRef.Name}
+	if namespaced {
+		key.Namespace = namespace
+	}
+
+	owner := &metav1.PartialObjectMetadata{}
+	owner.SetGroupVersionKind(gvk)
+	if err := c.Get(ctx, key, owner); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting owner %s %s: %w", gvk.GroupKind(), key, err)
+	}
+	if owner.UID != ownerRef.UID {
+		return nil, nil
+	}
+	return owner, nil
+}
+
+// dependentIndex lists all objects of the configured kinds and indexes them by the UIDs of their owners.
+// If namespace is not empty, only namespaced objects in that namespace are listed, since other objects
+// cannot be owned by an object in that namespace.
+func (o *OwnerGraphOptions) dependentIndex(ctx context.Context, c client.Client, namespace string) (map[types.UID][]*metav1.PartialObjectMetadata, error) {
+	kinds, err := o.kindsFor(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var listOpts []client.ListOption
+	if namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(namespace))
+	}
+
+	index := make(map[types.UID][]*metav1.PartialObjectMetadata)
+	for _, gvk := range kinds {
+		if namespace != "" {
+			namespaced, err := apiutil.IsGVKNamespaced(gvk, c.RESTMapper())
+			if err != nil {
+				if len(o.Kinds) == 0 && meta.IsNoMatchError(err) {
+					continue
+				}
+				return nil, fmt.Errorf("error determining scope of %s: %w", gvk, err)
+			}
+			if !namespaced {
+				continue
+			}
+		}
+
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := c.List(ctx, list, listOpts...); err != nil {
+			if len(o.Kinds) == 0 && isUnlistableError(err) {
+				// Not every searched kind can be listed, e.g. due to missing permissions.
+				continue
+			}
+			return nil, fmt.Errorf("error listing %s: %w", gvk.GroupKind(), err)
+		}
+
+		for i := range list.Items {
+			item := &list.Items[i]
+			item.SetGroupVersionKind(gvk)
+			for _, ownerRef := range item.OwnerReferences {
+				index[ownerRef.UID] = append(index[ownerRef.UID], item)
+			}
+		}
+	}
+
+	for _, deps := range index {
+		slices.SortFunc(deps, func(a, b *metav1.PartialObjectMetadata) int {
+			return CompareObjectRefs(
+				ObjectRef{GroupKind: a.GroupVersionKind().GroupKind(), Key: client.ObjectKeyFromObject(a)},
+				ObjectRef{GroupKind: b.GroupVersionKind().GroupKind(), Key: client.ObjectKeyFromObject(b)},
+			)
+		})
+	}
+	return index, nil
+}
+
+// kindsFor returns the configured kinds or, if none are configured, all listable kinds in their preferred
+// version, either as reported by Discovery or as known to the client's scheme and RESTMapper.
+func (o *OwnerGraphOptions) kindsFor(c clientMeta) ([]schema.GroupVersionKind, error) {
+	if len(o.Kinds) > 0 {
+		return o.Kinds, nil
+	}
+
+	var (
+		res []schema.GroupVersionKind
+		err error
+	)
+	if o.Discovery != nil {
+		res, err = discoveredKinds(o.Discovery)
+	} else {
+		res, err = schemeKinds(c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(res, func(a, b schema.GroupVersionKind) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return res, nil
+}
+
+// discoveredKinds returns all listable kinds served by the API server in their preferred version.
+// Groups whose discovery failed are skipped.
+func discoveredKinds(d discovery.DiscoveryInterface) ([]schema.GroupVersionKind, error) {
+	lists, err := discovery.ServerPreferredResources(d)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("error discovering server resources: %w", err)
+	}
+
+	var res []schema.GroupVersionKind
+	for _, list := range discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, lists) {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing group version %s: %w", list.GroupVersion, err)
+		}
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") {
+				// Subresources are no kinds of their own.
+				continue
+			}
+			res = append(res, gv.WithKind(resource.Kind))
+		}
+	}
+	return res, nil
+}
+
+// schemeKinds returns all listable kinds of the client's scheme known to its RESTMapper in their preferred
+// version. The version priority of the scheme takes precedence.
+func schemeKinds(c clientMeta) ([]schema.GroupVersionKind, error) {
+	seen := make(map[schema.GroupKind]bool)
+	var res []schema.GroupVersionKind
+	for gvk := range c.Scheme().AllKnownTypes() {
+		gk := gvk.GroupKind()
+		if gvk.Version == runtime.APIVersionInternal || seen[gk] || !isListableKind(c.Scheme(), gvk) {
+			continue
+		}
+		seen[gk] = true
+
+		var versions []string
+		for _, gv := range c.Scheme().PrioritizedVersionsForGroup(gk.Group) {
+			versions = append(versions, gv.Version)
+		}
+		mapping, err := c.RESTMapper().RESTMapping(gk, versions...)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error getting rest mapping for %s: %w", gk, err)
+		}
+		res = append(res, mapping.GroupVersionKind)
+	}
+	return res, nil
+}
+
+// isUnlistableError reports whether err indicates that a kind cannot be listed by the client.
+func isUnlistableError(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) ||
+		meta.IsNoMatchError(err)
+}
+
+// isListableKind reports whether the scheme knows a list type for the given kind.
+func isListableKind(scheme *runtime.Scheme, gvk schema.GroupVersionKind) bool {
+	list, err := scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	return err == nil && meta.IsListType(list)
+}
+
+func ownerReferenceTo(obj client.Object, uid types.UID) metav1.OwnerReference {
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.UID == uid {
+			return ownerRef
+		}
+	}
+	return metav1.OwnerReference{}
+}
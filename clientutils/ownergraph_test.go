@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("OwnerGraph", func() {
+	var (
+		ctx        context.Context
+		c          client.Client
+		deploy     *appsv1.Deployment
+		rs         *appsv1.ReplicaSet
+		pod1, pod2 *corev1.Pod
+		cm         *corev1.ConfigMap
+		kinds      DependentKinds
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		ownerRef := func(apiVersion, kind, name string, uid types.UID, controller bool) metav1.OwnerReference {
+			return metav1.OwnerReference{APIVersion: apiVersion, Kind: kind, Name: name, UID: uid, Controller: ptr.To(controller)}
+		}
+		objectMeta := func(name string, uid types.UID, ownerRefs ...metav1.OwnerReference) metav1.ObjectMeta {
+			return metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: name, UID: uid, OwnerReferences: ownerRefs}
+		}
+		deploy = &appsv1.Deployment{ObjectMeta: objectMeta("my-deploy", "deploy-uid")}
+		rs = &appsv1.ReplicaSet{ObjectMeta: objectMeta("my-rs", "rs-uid",
+			ownerRef("apps/v1", "Deployment", "my-deploy", "deploy-uid", true),
+		)}
+		pod1 = &corev1.Pod{ObjectMeta: objectMeta("pod-1", "pod-1-uid",
+			ownerRef("apps/v1", "ReplicaSet", "my-rs", "rs-uid", true),
+		)}
+		pod2 = &corev1.Pod{ObjectMeta: objectMeta("pod-2", "pod-2-uid",
+			ownerRef("apps/v1", "ReplicaSet", "my-rs", "rs-uid", true),
+			ownerRef("v1", "ConfigMap", "gone", "gone-uid", false),
+		)}
+		cm = &corev1.ConfigMap{ObjectMeta: objectMeta("my-cm", "cm-uid",
+			ownerRef("apps/v1", "Deployment", "my-deploy", "deploy-uid", false),
+		)}
+
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)).
+			WithObjects(deploy, rs, pod1, pod2, cm, &corev1.Pod{ObjectMeta: objectMeta("unrelated", "unrelated-uid")}).
+			Build()
+		kinds = DependentKinds{
+			corev1.SchemeGroupVersion.WithKind("Pod"),
+			corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+			appsv1.SchemeGroupVersion.WithKind("ReplicaSet"),
+		}
+	})
+
+	Describe("ListDependents", func() {
+		It("should list the direct dependents of the given kinds", func() {
+			deps, err := ListDependents(ctx, c, deploy, kinds)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deps).To(HaveLen(2))
+			Expect(deps[0].Name).To(Equal("my-cm"))
+			Expect(deps[1].Name).To(Equal("my-rs"))
+			Expect(deps[1].GroupVersionKind()).To(Equal(appsv1.SchemeGroupVersion.WithKind("ReplicaSet")))
+		})
+
+		It("should search all kinds known to the scheme and rest mapper by default", func() {
+			deps, err := ListDependents(ctx, c, rs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deps).To(HaveLen(2))
+			Expect(deps[0].Name).To(Equal("pod-1"))
+			Expect(deps[1].Name).To(Equal("pod-2"))
+		})
+
+		It("should search all kinds reported by discovery, skipping kinds that cannot be listed", func() {
+			widgetGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Widget"}
+			widgetMapper := meta.NewDefaultRESTMapper(nil)
+			widgetMapper.Add(widgetGVK, meta.RESTScopeNamespace)
+
+			c = fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithRESTMapper(meta.MultiRESTMapper{testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme), widgetMapper}).
+				WithObjects(rs, pod1).
+				WithInterceptorFuncs(interceptor.Funcs{
+					List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+						switch list.GetObjectKind().GroupVersionKind().Kind {
+						case "SecretList":
+							return apierrors.NewForbidden(corev1.Resource("secrets"), "", errors.New("not allowed"))
+						case "WidgetList":
+							widget := metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+								Namespace:       corev1.NamespaceDefault,
+								Name:            "my-widget",
+								OwnerReferences: pod1.OwnerReferences,
+							}}
+							list.(*metav1.PartialObjectMetadataList).Items = []metav1.PartialObjectMetadata{widget}
+							return nil
+						}
+						return c.List(ctx, list, opts...)
+					},
+				}).
+				Build()
+
+			listVerbs := metav1.Verbs{"get", "list"}
+			d := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+				{GroupVersion: "v1", APIResources: []metav1.APIResource{
+					{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: listVerbs},
+					{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get"}},
+					{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: listVerbs},
+				}},
+				{GroupVersion: "example.org/v1", APIResources: []metav1.APIResource{
+					{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: listVerbs},
+				}},
+			}}}
+
+			deps, err := ListDependents(ctx, c, rs, DiscoverDependentKinds{Discovery: d})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deps).To(HaveLen(2))
+			Expect(deps[0].Name).To(Equal("pod-1"))
+			Expect(deps[1].Name).To(Equal("my-widget"))
+			Expect(deps[1].GroupVersionKind()).To(Equal(widgetGVK))
+		})
+	})
+
+	Describe("DependentTree", func() {
+		It("should build and print the tree of all transitive dependents", func() {
+			tree, err := DependentTree(ctx, c, deploy, kinds)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tree.Object.UID).To(Equal(types.UID("deploy-uid")))
+			Expect(tree.Dependents).To(HaveLen(2))
+			Expect(tree.Dependents[1].Controller).To(BeTrue())
+			Expect(tree.Dependents[1].Dependents).To(HaveLen(2))
+
+			Expect(tree.String()).To(Equal(`Deployment.apps/default/my-deploy
+├── ConfigMap/default/my-cm
+└── ReplicaSet.apps/default/my-rs (controller)
+    ├── Pod/default/pod-1 (controller)
+    └── Pod/default/pod-2 (controller)
+`))
+		})
+	})
+
+	Describe("ListAncestors", func() {
+		It("should walk up the owner references, skipping owners that do not exist", func() {
+			ancestors, err := ListAncestors(ctx, c, pod2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ancestors).To(HaveLen(2))
+			Expect(ancestors[0].Name).To(Equal("my-rs"))
+			Expect(ancestors[1].Name).To(Equal("my-deploy"))
+		})
+
+		It("should skip owners whose uid does not match", func() {
+			rs.OwnerReferences[0].UID = "other-uid"
+			Expect(c.Update(ctx, rs)).To(Succeed())
+
+			ancestors, err := ListAncestors(ctx, c, pod1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ancestors).To(HaveLen(1))
+			Expect(ancestors[0].Name).To(Equal("my-rs"))
+		})
+	})
+})
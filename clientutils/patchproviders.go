@@ -0,0 +1,337 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ironcore-dev/controller-utils/unstructuredutils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// PatchBases are the base objects patches are computed against, keyed by their ObjectRef.
+type PatchBases map[ObjectRef]client.Object
+
+// NewPatchBases creates new PatchBases from the given objects, e.g. a snapshot read from a file
+// (see ExportSnapshot).
+func NewPatchBases(scheme *runtime.Scheme, objs []client.Object) (PatchBases, error) {
+	bases := make(PatchBases, len(objs))
+	for _, obj := range objs {
+		ref, err := ObjectRefFromObject(scheme, obj)
+		if err != nil {
+			return nil, fmt.Errorf("error getting object ref of %s: %w", client.ObjectKeyFromObject(obj), err)
+		}
+		bases[ref] = obj
+	}
+	return bases, nil
+}
+
+// PatchBasesFromFile reads the given file as unstructured objects and creates new PatchBases from them.
+func PatchBasesFromFile(scheme *runtime.Scheme, filename string) (PatchBases, error) {
+	objs, err := unstructuredutils.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	return NewPatchBases(scheme, unstructuredutils.UnstructuredSliceToObjectSliceNoCopy(objs))
+}
+
+// GetPatchBases gets the live state of the given objects as PatchBases. Objects that do not exist are omitted.
+// The given objects are not modified.
+func GetPatchBases(ctx context.Context, c client.Client, objs []client.Object) (PatchBases, error) {
+	bases := make(PatchBases, len(objs))
+	for _, obj := range objs {
+		ref, err := ObjectRefFromObject(c.Scheme(), obj)
+		if err != nil {
+			return nil, fmt.Errorf("error getting object ref of %s: %w", client.ObjectKeyFromObject(obj), err)
+		}
+
+		base := obj.DeepCopyObject().(client.Object)
+		if err := c.Get(ctx, ref.Key, base); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error getting object %s: %w", ref, err)
+		}
+		bases[ref] = base
+	}
+	return bases, nil
+}
+
+// MergeFromProvider is a PatchProvider providing JSON merge patches (see client.MergeFrom) of each object against
+// its base.
+type MergeFromProvider struct {
+	// Scheme is used to determine the ObjectRef of the objects to look up their base.
+	Scheme *runtime.Scheme
+	// Bases are the bases of the objects. Patching an object without base fails.
+	Bases PatchBases
+	// OptimisticLock makes the patches fail with a conflict if the resource version of an object differs from
+	// the one of its base.
+	OptimisticLock bool
+}
+
+// PatchFor implements PatchProvider.
+func (p MergeFromProvider) PatchFor(obj client.Object) client.Patch {
+	return &basePatch{
+		patchType:      types.MergePatchType,
+		scheme:         p.Scheme,
+		bases:          p.Bases,
+		optimisticLock: p.OptimisticLock,
+		create:         createMergePatch,
+	}
+}
+
+// StrategicMergeFromProvider is a PatchProvider providing strategic merge patches (see client.StrategicMergeFrom)
+// of each object against its base. The patch strategies are looked up from the types registered in the scheme,
+// so it only supports registered types like the built-in ones, also for unstructured objects.
+type StrategicMergeFromProvider struct {
+	// Scheme is used to determine the ObjectRef of the objects to look up their base and the types
+	// to look up the patch strategies.
+	Scheme *runtime.Scheme
+	// Bases are the bases of the objects. Patching an object without base fails.
+	Bases PatchBases
+	// OptimisticLock makes the patches fail with a conflict if the resource version of an object differs from
+	// the one of its base.
+	OptimisticLock bool
+}
+
+// PatchFor implements PatchProvider.
+func (p StrategicMergeFromProvider) PatchFor(obj client.Object) client.Patch {
+	return &basePatch{
+		patchType:      types.StrategicMergePatchType,
+		scheme:         p.Scheme,
+		bases:          p.Bases,
+		optimisticLock: p.OptimisticLock,
+		create:         createStrategicMergePatch,
+	}
+}
+
+// JSONPatchProvider is a PatchProvider providing RFC 6902 JSON patches generated from the difference of
+// each object to its base. Lists are replaced as a whole.
+type JSONPatchProvider struct {
+	// Scheme is used to determine the ObjectRef of the objects to look up their base.
+	Scheme *runtime.Scheme
+	// Bases are the bases of the objects. Patching an object without base fails.
+	Bases PatchBases
+	// OptimisticLock makes the patches start with a test operation on the resource version of the base,
+	// so they fail if the resource version of an object differs from it.
+	OptimisticLock bool
+	// Test precedes every replace and remove operation with a test operation on the value of the base,
+	// so the patches fail if any value they change differs from the base.
+	Test bool
+}
+
+// PatchFor implements PatchProvider.
+func (p JSONPatchProvider) PatchFor(obj client.Object) client.Patch {
+	return &basePatch{
+		patchType:      types.JSONPatchType,
+		scheme:         p.Scheme,
+		bases:          p.Bases,
+		optimisticLock: p.OptimisticLock,
+		create: func(scheme *runtime.Scheme, obj client.Object, base, modified map[string]any, resourceVersion string) ([]byte, error) {
+			return createJSONPatch(base, modified, resourceVersion, p.Test)
+		},
+	}
+}
+
+// basePatch is a client.Patch computed from the difference of an object to its base.
+type basePatch struct {
+	patchType      types.PatchType
+	scheme         *runtime.Scheme
+	bases          PatchBases
+	optimisticLock bool
+	// create creates the patch data from the base and modified object content, excluding any server-managed fields.
+	// If resourceVersion is not empty, the patch has to fail if the resource version of the object differs.
+	create func(scheme *runtime.Scheme, obj client.Object, base, modified map[string]any, resourceVersion string) ([]byte, error)
+}
+
+// Type implements client.Patch.
+func (p *basePatch) Type() types.PatchType {
+	return p.patchType
+}
+
+// Data implements client.Patch.
+func (p *basePatch) Data(obj client.Object) ([]byte, error) {
+	ref, err := ObjectRefFromObject(p.scheme, obj)
+	if err != nil {
+		return nil, fmt.Errorf("error getting object ref of %s: %w", client.ObjectKeyFromObject(obj), err)
+	}
+	base, ok := p.bases[ref]
+	if !ok {
+		return nil, fmt.Errorf("no patch base for %s", ref)
+	}
+
+	var resourceVersion string
+	if p.optimisticLock {
+		resourceVersion = base.GetResourceVersion()
+		if resourceVersion == "" {
+			return nil, fmt.Errorf("cannot use optimistic lock, patch base of %s does not have a resource version", ref)
+		}
+	}
+
+	baseContent, err := patchContent(base)
+	if err != nil {
+		return nil, fmt.Errorf("error converting patch base of %s: %w", ref, err)
+	}
+	modifiedContent, err := patchContent(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error converting object %s: %w", ref, err)
+	}
+	return p.create(p.scheme, obj, baseContent, modifiedContent, resourceVersion)
+}
+
+// patchContent returns the content of the given object without its type meta and server-managed metadata fields
+// (see serverMetadataFields).
+func patchContent(obj client.Object) (map[string]any, error) {
+	// Convert a copy, as the content of unstructured objects is returned as-is.
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj.DeepCopyObject())
+	if err != nil {
+		return nil, err
+	}
+	delete(content, "apiVersion")
+	delete(content, "kind")
+	removeServerMetadataFields(content)
+	return content, nil
+}
+
+// withResourceVersion sets the given resource version in the metadata of the given patch content.
+func withResourceVersion(content map[string]any, resourceVersion string) (map[string]any, error) {
+	content = runtime.DeepCopyJSON(content)
+	if err := unstructured.SetNestedField(content, resourceVersion, "metadata", "resourceVersion"); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func createMergePatch(_ *runtime.Scheme, _ client.Object, base, modified map[string]any, resourceVersion string) ([]byte, error) {
+	if resourceVersion != "" {
+		var err error
+		if modified, err = withResourceVersion(modified, resourceVersion); err != nil {
+			return nil, err
+		}
+	}
+	return client.MergeFrom(&unstructured.Unstructured{Object: base}).Data(&unstructured.Unstructured{Object: modified})
+}
+
+func createStrategicMergePatch(scheme *runtime.Scheme, obj client.Object, base, modified map[string]any, resourceVersion string) ([]byte, error) {
+	dataStruct := runtime.Object(obj)
+	if _, ok := obj.(runtime.Unstructured); ok {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		if dataStruct, err = scheme.New(gvk); err != nil {
+			return nil, fmt.Errorf("strategic merge patches are only supported for registered types: %w", err)
+		}
+	}
+
+	if resourceVersion != "" {
+		var err error
+		if modified, err = withResourceVersion(modified, resourceVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	modifiedJSON, err := json.Marshal(modified)
+	if err != nil {
+		return nil, err
+	}
+	return strategicpatch.CreateTwoWayMergePatch(baseJSON, modifiedJSON, dataStruct)
+}
+
+func createJSONPatch(base, modified map[string]any, resourceVersion string, test bool) ([]byte, error) {
+	ops := []jsonPatchOp{}
+	if resourceVersion != "" {
+		var err error
+		if ops, err = appendJSONPatchOp(ops, "test", "/metadata/resourceVersion", resourceVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	ops, err := appendJSONPatchDiff(ops, "", base, modified, test)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ops)
+}
+
+// escapeJSONPointer escapes the given key to be used as a JSON pointer reference token, see RFC 6901.
+func escapeJSONPointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func appendJSONPatchOp(ops []jsonPatchOp, op, path string, value any) ([]jsonPatchOp, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling value of %s: %w", path, err)
+	}
+	return append(ops, jsonPatchOp{Op: op, Path: path, Value: data}), nil
+}
+
+// appendJSONPatchDiff appends the operations transforming the base into the modified map at the given path.
+// Keys are processed in sorted order to create deterministic patches.
+func appendJSONPatchDiff(ops []jsonPatchOp, path string, base, modified map[string]any, test bool) ([]jsonPatchOp, error) {
+	var err error
+	for _, key := range slices.Sorted(maps.Keys(base)) {
+		if _, ok := modified[key]; ok {
+			continue
+		}
+
+		keyPath := path + "/" + escapeJSONPointer(key)
+		if test {
+			if ops, err = appendJSONPatchOp(ops, "test", keyPath, base[key]); err != nil {
+				return nil, err
+			}
+		}
+		ops = append(ops, jsonPatchOp{Op: "remove", Path: keyPath})
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(modified)) {
+		keyPath := path + "/" + escapeJSONPointer(key)
+		baseValue, ok := base[key]
+		if !ok {
+			if ops, err = appendJSONPatchOp(ops, "add", keyPath, modified[key]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if reflect.DeepEqual(baseValue, modified[key]) {
+			continue
+		}
+
+		baseMap, baseIsMap := baseValue.(map[string]any)
+		modifiedMap, modifiedIsMap := modified[key].(map[string]any)
+		if baseIsMap && modifiedIsMap {
+			if ops, err = appendJSONPatchDiff(ops, keyPath, baseMap, modifiedMap, test); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if test {
+			if ops, err = appendJSONPatchOp(ops, "test", keyPath, baseValue); err != nil {
+				return nil, err
+			}
+		}
+		if ops, err = appendJSONPatchOp(ops, "replace", keyPath, modified[key]); err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
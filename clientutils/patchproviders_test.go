@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	"github.com/ironcore-dev/controller-utils/testdata"
+	"github.com/ironcore-dev/controller-utils/unstructuredutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("PatchProviders", func() {
+	const objectsPath = "../testdata/bases/objects.yaml"
+	var (
+		ctx    context.Context
+		c      client.Client
+		cmKey  = client.ObjectKey{Namespace: "default", Name: "cm"}
+		liveCM *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		liveCM = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cmKey.Namespace, Name: cmKey.Name, Labels: map[string]string{"live": "true"}},
+			Data:       map[string]string{"a": "1", "b": "2"},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(liveCM).Build()
+	})
+
+	snapshotCM := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cmKey.Namespace, Name: cmKey.Name},
+			Data:       map[string]string{"a": "1", "b": "2"},
+		}
+	}
+
+	desiredCM := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cmKey.Namespace, Name: cmKey.Name},
+			Data:       map[string]string{"b": "3", "c": "4"},
+		}
+	}
+
+	basesOf := func(objs ...client.Object) PatchBases {
+		bases, err := NewPatchBases(scheme.Scheme, objs)
+		Expect(err).NotTo(HaveOccurred())
+		return bases
+	}
+
+	updateLiveCM := func() {
+		cm := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, cmKey, cm)).To(Succeed())
+		cm.Data["b"] = "5"
+		Expect(c.Update(ctx, cm)).To(Succeed())
+	}
+
+	Describe("MergeFromProvider", func() {
+		It("should patch the difference of the object to its base", func() {
+			provider := MergeFromProvider{Scheme: scheme.Scheme, Bases: basesOf(snapshotCM())}
+			obj := desiredCM()
+
+			patch := provider.PatchFor(obj)
+			Expect(patch.Data(obj)).To(MatchJSON(`{"data":{"a":null,"b":"3","c":"4"}}`))
+
+			Expect(PatchMultiple(ctx, c, PatchRequestsFromObjectsAndProvider([]client.Object{obj}, provider))).To(Succeed())
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, cmKey, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(map[string]string{"b": "3", "c": "4"}))
+			Expect(cm.Labels).To(Equal(map[string]string{"live": "true"}))
+		})
+
+		It("should fail with a conflict if the object changed since getting its base", func() {
+			bases, err := GetPatchBases(ctx, c, []client.Object{desiredCM()})
+			Expect(err).NotTo(HaveOccurred())
+			updateLiveCM()
+
+			provider := MergeFromProvider{Scheme: scheme.Scheme, Bases: bases, OptimisticLock: true}
+			obj := desiredCM()
+			err = c.Patch(ctx, obj, provider.PatchFor(obj))
+			Expect(apierrors.IsConflict(err)).To(BeTrue(), "expected conflict but got %v", err)
+		})
+
+		It("should error if there is no base for the object", func() {
+			provider := MergeFromProvider{Scheme: scheme.Scheme, Bases: PatchBases{}}
+			obj := desiredCM()
+			_, err := provider.PatchFor(obj).Data(obj)
+			Expect(err).To(MatchError(ContainSubstring("no patch base for ConfigMap/default/cm")))
+		})
+
+		It("should patch the objects of a file", func() {
+			c = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+				testdata.Secret(),
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "my-configmap", Labels: map[string]string{"live": "true"}},
+					Data:       map[string]string{"baz": "other"},
+				},
+			).Build()
+			objs, err := unstructuredutils.ReadFile(objectsPath)
+			Expect(err).NotTo(HaveOccurred())
+			bases, err := GetPatchBases(ctx, c, unstructuredutils.UnstructuredSliceToObjectSliceNoCopy(objs))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bases).To(HaveLen(2))
+
+			_, err = PatchMultipleFromFile(ctx, c, objectsPath, MergeFromProvider{Scheme: scheme.Scheme, Bases: bases, OptimisticLock: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "kube-system", Name: "my-configmap"}, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(map[string]string{"baz": "qux"}))
+			Expect(cm.Labels).To(BeEmpty())
+		})
+	})
+
+	Describe("StrategicMergeFromProvider", func() {
+		It("should merge lists according to the patch strategy of the type", func() {
+			podKey := client.ObjectKey{Namespace: "default", Name: "pod"}
+			newPod := func(containers ...corev1.Container) *corev1.Pod {
+				return &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Namespace: podKey.Namespace, Name: podKey.Name},
+					Spec:       corev1.PodSpec{Containers: containers},
+				}
+			}
+			main := corev1.Container{Name: "main", Image: "main:v1"}
+			sidecar := corev1.Container{Name: "sidecar", Image: "sidecar:v1"}
+			Expect(c.Create(ctx, newPod(main, sidecar))).To(Succeed())
+
+			provider := StrategicMergeFromProvider{Scheme: scheme.Scheme, Bases: basesOf(newPod(main))}
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newPod(corev1.Container{Name: "main", Image: "main:v2"}))
+			Expect(err).NotTo(HaveOccurred())
+			obj := &unstructured.Unstructured{Object: content}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("Pod")
+
+			Expect(c.Patch(ctx, obj, provider.PatchFor(obj))).To(Succeed())
+
+			pod := &corev1.Pod{}
+			Expect(c.Get(ctx, podKey, pod)).To(Succeed())
+			Expect(pod.Spec.Containers).To(ConsistOf(
+				HaveField("Image", "main:v2"),
+				HaveField("Image", "sidecar:v1"),
+			))
+		})
+
+		It("should error for types that are not registered", func() {
+			newObj := func() *unstructured.Unstructured {
+				obj := &unstructured.Unstructured{}
+				obj.SetAPIVersion("example.com/v1")
+				obj.SetKind("Foo")
+				obj.SetNamespace("default")
+				obj.SetName("foo")
+				return obj
+			}
+			provider := StrategicMergeFromProvider{Scheme: scheme.Scheme, Bases: basesOf(newObj())}
+			obj := newObj()
+			_, err := provider.PatchFor(obj).Data(obj)
+			Expect(err).To(MatchError(ContainSubstring("only supported for registered types")))
+		})
+	})
+
+	Describe("JSONPatchProvider", func() {
+		It("should create a patch from the difference of the object to its base", func() {
+			provider := JSONPatchProvider{Scheme: scheme.Scheme, Bases: basesOf(snapshotCM())}
+			obj := desiredCM()
+			obj.Labels = map[string]string{"app.kubernetes.io/name": "foo"}
+
+			Expect(provider.PatchFor(obj).Data(obj)).To(MatchJSON(`[
+				{"op":"remove","path":"/data/a"},
+				{"op":"replace","path":"/data/b","value":"3"},
+				{"op":"add","path":"/data/c","value":"4"},
+				{"op":"add","path":"/metadata/labels","value":{"app.kubernetes.io/name":"foo"}}
+			]`))
+
+			Expect(c.Patch(ctx, obj, provider.PatchFor(obj))).To(Succeed())
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, cmKey, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(map[string]string{"b": "3", "c": "4"}))
+			Expect(cm.Labels).To(Equal(map[string]string{"app.kubernetes.io/name": "foo"}))
+		})
+
+		It("should precede changes with test operations", func() {
+			base := snapshotCM()
+			base.ResourceVersion = "999"
+			provider := JSONPatchProvider{Scheme: scheme.Scheme, Bases: basesOf(base), OptimisticLock: true, Test: true}
+			obj := desiredCM()
+
+			Expect(provider.PatchFor(obj).Data(obj)).To(MatchJSON(`[
+				{"op":"test","path":"/metadata/resourceVersion","value":"999"},
+				{"op":"test","path":"/data/a","value":"1"},
+				{"op":"remove","path":"/data/a"},
+				{"op":"test","path":"/data/b","value":"2"},
+				{"op":"replace","path":"/data/b","value":"3"},
+				{"op":"add","path":"/data/c","value":"4"}
+			]`))
+
+			Expect(c.Patch(ctx, obj, provider.PatchFor(obj))).To(Succeed())
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, cmKey, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(map[string]string{"b": "3", "c": "4"}))
+		})
+
+		It("should fail if a tested value differs from the base", func() {
+			updateLiveCM()
+
+			provider := JSONPatchProvider{Scheme: scheme.Scheme, Bases: basesOf(snapshotCM()), Test: true}
+			obj := desiredCM()
+			Expect(c.Patch(ctx, obj, provider.PatchFor(obj))).NotTo(Succeed())
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, cmKey, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(map[string]string{"a": "1", "b": "5"}))
+		})
+	})
+})
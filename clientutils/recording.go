@@ -0,0 +1,405 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Operation is an operation run through a RecordingClient or FaultClient.
+type Operation struct {
+	// Verb is the verb of the operation. Patches with client.Apply are server-side applies and have VerbApply.
+	Verb Verb
+	// SubResource is the subresource the operation was run on, e.g. 'status'. Empty for the main resource.
+	SubResource string
+	// GVK is the group version kind of the object. For list operations, it is the kind of the list items.
+	// It is empty if it could not be determined.
+	GVK schema.GroupVersionKind
+	// Key is the key of the object. For list and delete all of operations, only its namespace is set.
+	Key client.ObjectKey
+	// Options are the options passed to the operation.
+	Options []any
+	// PatchType is the type of the patch for patch and apply operations.
+	PatchType types.PatchType
+	// PatchData is the data of the patch for patch and apply operations.
+	PatchData []byte
+	// Object is the object (or list) of the operation. A RecordingClient records a copy of it after the operation.
+	// It is nil for delete all of operations.
+	Object runtime.Object
+	// Err is the error returned by the operation. It is only set by a RecordingClient.
+	Err error
+}
+
+// Ref returns the ObjectRef of the object of the operation.
+func (op Operation) Ref() ObjectRef {
+	return ObjectRef{GroupKind: op.GVK.GroupKind(), Key: op.Key}
+}
+
+// String returns a human-readable representation of the operation.
+func (op Operation) String() string {
+	var sb strings.Builder
+	sb.WriteString(string(op.Verb))
+	if op.SubResource != "" {
+		fmt.Fprintf(&sb, " %s", op.SubResource)
+	}
+	fmt.Fprintf(&sb, " %s", op.Ref())
+	if op.Err != nil {
+		fmt.Fprintf(&sb, ": %v", op.Err)
+	}
+	return sb.String()
+}
+
+// Matches reports whether the operation is selected by all given filters.
+func (op Operation) Matches(filters ...OperationFilter) bool {
+	for _, filter := range filters {
+		if !filter(op) {
+			return false
+		}
+	}
+	return true
+}
+
+// OperationFilter reports whether an Operation should be selected.
+type OperationFilter func(op Operation) bool
+
+// OperationVerbs selects operations with any of the given verbs.
+func OperationVerbs(verbs ...Verb) OperationFilter {
+	return func(op Operation) bool {
+		return slices.Contains(verbs, op.Verb)
+	}
+}
+
+// OperationSubResource selects operations on the given subresource. Use "" to select operations on the
+// main resource.
+func OperationSubResource(subResource string) OperationFilter {
+	return func(op Operation) bool {
+		return op.SubResource == subResource
+	}
+}
+
+// OperationKind selects operations on objects of the given GroupKind.
+func OperationKind(gk schema.GroupKind) OperationFilter {
+	return func(op Operation) bool {
+		return op.GVK.GroupKind() == gk
+	}
+}
+
+// OperationObject selects operations on the referenced object.
+func OperationObject(ref ObjectRef) OperationFilter {
+	return func(op Operation) bool {
+		return op.Ref() == ref
+	}
+}
+
+// OperationNamespace selects operations in the given namespace, including list and delete all of operations.
+func OperationNamespace(namespace string) OperationFilter {
+	return func(op Operation) bool {
+		return op.Key.Namespace == namespace
+	}
+}
+
+// OperationLabelSelector selects operations on objects whose labels match the given selector.
+// Operations without an object, e.g. list operations, are not selected.
+func OperationLabelSelector(sel labels.Selector) OperationFilter {
+	return func(op Operation) bool {
+		obj, ok := op.Object.(metav1.Object)
+		return ok && sel.Matches(labels.Set(obj.GetLabels()))
+	}
+}
+
+// OperationSucceeded selects operations that did not return an error.
+func OperationSucceeded() OperationFilter {
+	return func(op Operation) bool {
+		return op.Err == nil
+	}
+}
+
+var writeVerbs = []Verb{VerbCreate, VerbUpdate, VerbPatch, VerbApply, VerbDelete, VerbDeleteAllOf}
+
+func isWriteVerb(verb Verb) bool {
+	return slices.Contains(writeVerbs, verb)
+}
+
+// OperationWrites selects all operations that modify objects.
+func OperationWrites() OperationFilter {
+	return OperationVerbs(writeVerbs...)
+}
+
+// RecordingClient is a client.Client that records all operations done through it.
+// It is safe for concurrent use.
+type RecordingClient struct {
+	client.Client
+
+	mu         sync.Mutex
+	operations []Operation
+}
+
+// NewRecordingClient creates a new RecordingClient that runs all operations using the given client.
+func NewRecordingClient(c client.Client) *RecordingClient {
+	return &RecordingClient{Client: c}
+}
+
+// Operations returns the recorded operations in the order they completed, optionally only the ones
+// selected by all given filters.
+func (r *RecordingClient) Operations(filters ...OperationFilter) []Operation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res []Operation
+	for _, op := range r.operations {
+		if op.Matches(filters...) {
+			res = append(res, op)
+		}
+	}
+	return res
+}
+
+// Reset removes all recorded operations.
+func (r *RecordingClient) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = nil
+}
+
+func (r *RecordingClient) record(op Operation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, op)
+}
+
+func toAnySlice[E any](s []E) []any {
+	if len(s) == 0 {
+		return nil
+	}
+	res := make([]any, len(s))
+	for i, e := range s {
+		res[i] = e
+	}
+	return res
+}
+
+func (r *RecordingClient) recordObject(verb Verb, subResource string, obj client.Object, opts []any, err error) {
+	gvk, _ := r.GroupVersionKindFor(obj)
+	r.record(Operation{
+		Verb:        verb,
+		SubResource: subResource,
+		GVK:         gvk,
+		Key:         client.ObjectKeyFromObject(obj),
+		Options:     opts,
+		Object:      obj.DeepCopyObject(),
+		Err:         err,
+	})
+}
+
+func (r *RecordingClient) recordPatch(subResource string, obj client.Object, patch client.Patch, opts []any, do func() error) error {
+	data, dataErr := patch.Data(obj)
+	err := do()
+
+	gvk, _ := r.GroupVersionKindFor(obj)
+	op := Operation{
+		Verb:        patchVerb(patch),
+		SubResource: subResource,
+		GVK:         gvk,
+		Key:         client.ObjectKeyFromObject(obj),
+		Options:     opts,
+		PatchType:   patch.Type(),
+		Object:      obj.DeepCopyObject(),
+		Err:         err,
+	}
+	if dataErr == nil {
+		op.PatchData = data
+	}
+	r.record(op)
+	return err
+}
+
+// patchVerb returns VerbApply for server-side apply patches and VerbPatch for all others.
+func patchVerb(patch client.Patch) Verb {
+	if patch.Type() == types.ApplyPatchType {
+		return VerbApply
+	}
+	return VerbPatch
+}
+
+// applyConfigurationObject converts the given apply configuration to unstructured, returning nil if it cannot
+// be converted.
+func applyConfigurationObject(obj runtime.ApplyConfiguration) *unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func (r *RecordingClient) recordApply(subResource string, obj runtime.ApplyConfiguration, opts []any, err error) {
+	op := newApplyOperation(subResource, obj, opts)
+	op.Err = err
+	r.record(op)
+}
+
+func newApplyOperation(subResource string, obj runtime.ApplyConfiguration, opts []any) Operation {
+	op := Operation{
+		Verb:        VerbApply,
+		SubResource: subResource,
+		Options:     opts,
+		PatchType:   types.ApplyPatchType,
+	}
+	if u := applyConfigurationObject(obj); u != nil {
+		op.GVK = u.GroupVersionKind()
+		op.Key = client.ObjectKeyFromObject(u)
+		op.Object = u
+	}
+	if data, err := json.Marshal(obj); err == nil {
+		op.PatchData = data
+	}
+	return op
+}
+
+func (r *RecordingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	err := r.Client.Get(ctx, key, obj, opts...)
+	gvk, _ := r.GroupVersionKindFor(obj)
+	r.record(Operation{
+		Verb:    VerbGet,
+		GVK:     gvk,
+		Key:     key,
+		Options: toAnySlice(opts),
+		Object:  obj.DeepCopyObject(),
+		Err:     err,
+	})
+	return err
+}
+
+// listItemGVK returns the group version kind of the items of the given list. It is empty if it cannot be
+// determined.
+func listItemGVK(c clientMeta, list client.ObjectList) schema.GroupVersionKind {
+	gvk, err := c.GroupVersionKindFor(list)
+	if err != nil {
+		return schema.GroupVersionKind{}
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	return gvk
+}
+
+func (r *RecordingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	err := r.Client.List(ctx, list, opts...)
+	o := &client.ListOptions{}
+	o.ApplyOptions(opts)
+	r.record(Operation{
+		Verb:    VerbList,
+		GVK:     listItemGVK(r, list),
+		Key:     client.ObjectKey{Namespace: o.Namespace},
+		Options: toAnySlice(opts),
+		Object:  list.DeepCopyObject(),
+		Err:     err,
+	})
+	return err
+}
+
+func (r *RecordingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	err := r.Client.Create(ctx, obj, opts...)
+	r.recordObject(VerbCreate, "", obj, toAnySlice(opts), err)
+	return err
+}
+
+func (r *RecordingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	err := r.Client.Update(ctx, obj, opts...)
+	r.recordObject(VerbUpdate, "", obj, toAnySlice(opts), err)
+	return err
+}
+
+func (r *RecordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return r.recordPatch("", obj, patch, toAnySlice(opts), func() error {
+		return r.Client.Patch(ctx, obj, patch, opts...)
+	})
+}
+
+func (r *RecordingClient) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	err := r.Client.Apply(ctx, obj, opts...)
+	r.recordApply("", obj, toAnySlice(opts), err)
+	return err
+}
+
+func (r *RecordingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	err := r.Client.Delete(ctx, obj, opts...)
+	r.recordObject(VerbDelete, "", obj, toAnySlice(opts), err)
+	return err
+}
+
+func (r *RecordingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	err := r.Client.DeleteAllOf(ctx, obj, opts...)
+	o := &client.DeleteAllOfOptions{}
+	o.ApplyOptions(opts)
+	gvk, _ := r.GroupVersionKindFor(obj)
+	r.record(Operation{
+		Verb:    VerbDeleteAllOf,
+		GVK:     gvk,
+		Key:     client.ObjectKey{Namespace: o.Namespace},
+		Options: toAnySlice(opts),
+		Err:     err,
+	})
+	return err
+}
+
+func (r *RecordingClient) Status() client.SubResourceWriter {
+	return &recordingSubResourceWriter{r.Client.Status(), r, "status"}
+}
+
+func (r *RecordingClient) SubResource(subResource string) client.SubResourceClient {
+	c := r.Client.SubResource(subResource)
+	return &recordingSubResourceClient{c, recordingSubResourceWriter{c, r, subResource}}
+}
+
+type recordingSubResourceWriter struct {
+	client.SubResourceWriter
+	r           *RecordingClient
+	subResource string
+}
+
+func (w *recordingSubResourceWriter) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	err := w.SubResourceWriter.Create(ctx, obj, subResource, opts...)
+	w.r.recordObject(VerbCreate, w.subResource, obj, toAnySlice(opts), err)
+	return err
+}
+
+func (w *recordingSubResourceWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	err := w.SubResourceWriter.Update(ctx, obj, opts...)
+	w.r.recordObject(VerbUpdate, w.subResource, obj, toAnySlice(opts), err)
+	return err
+}
+
+func (w *recordingSubResourceWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	return w.r.recordPatch(w.subResource, obj, patch, toAnySlice(opts), func() error {
+		return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+	})
+}
+
+func (w *recordingSubResourceWriter) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
+	err := w.SubResourceWriter.Apply(ctx, obj, opts...)
+	w.r.recordApply(w.subResource, obj, toAnySlice(opts), err)
+	return err
+}
+
+type recordingSubResourceClient struct {
+	reader client.SubResourceReader
+	recordingSubResourceWriter
+}
+
+func (c *recordingSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	err := c.reader.Get(ctx, obj, subResource, opts...)
+	c.r.recordObject(VerbGet, c.subResource, obj, toAnySlice(opts), err)
+	return err
+}
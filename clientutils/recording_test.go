@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/ironcore-dev/controller-utils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("RecordingClient", func() {
+	var (
+		ctx   context.Context
+		c     *RecordingClient
+		cm    *corev1.ConfigMap
+		cmRef ObjectRef
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = NewRecordingClient(fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithStatusSubresource(&corev1.Pod{}).
+			Build())
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-cm"}}
+		cmRef = ObjectRef{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Key: client.ObjectKeyFromObject(cm)}
+	})
+
+	It("should record all operations with their details", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+		base := cm.DeepCopy()
+		cm.Data = map[string]string{"foo": "bar"}
+		Expect(c.Patch(ctx, cm, client.MergeFrom(base))).To(Succeed())
+		Expect(c.List(ctx, &corev1.ConfigMapList{}, client.InNamespace(corev1.NamespaceDefault))).To(Succeed())
+		err := c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "missing"}, &corev1.ConfigMap{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		ops := c.Operations()
+		Expect(ops).To(HaveLen(4))
+
+		Expect(ops[0].Verb).To(Equal(VerbCreate))
+		Expect(ops[0].Ref()).To(Equal(cmRef))
+		Expect(ops[0].Object.(*corev1.ConfigMap).Data).To(BeNil())
+
+		Expect(ops[1].Verb).To(Equal(VerbPatch))
+		Expect(ops[1].PatchType).To(Equal(types.MergePatchType))
+		Expect(string(ops[1].PatchData)).To(Equal(`{"data":{"foo":"bar"}}`))
+		Expect(ops[1].Object.(*corev1.ConfigMap).Data).To(Equal(map[string]string{"foo": "bar"}))
+
+		Expect(ops[2].Verb).To(Equal(VerbList))
+		Expect(ops[2].GVK).To(Equal(corev1.SchemeGroupVersion.WithKind("ConfigMap")))
+		Expect(ops[2].Key).To(Equal(client.ObjectKey{Namespace: corev1.NamespaceDefault}))
+		Expect(ops[2].Options).To(Equal([]any{client.InNamespace(corev1.NamespaceDefault)}))
+
+		Expect(ops[3].Verb).To(Equal(VerbGet))
+		Expect(apierrors.IsNotFound(ops[3].Err)).To(BeTrue())
+	})
+
+	It("should record server-side applies via patch as applies", func() {
+		applied := cm.DeepCopy()
+		applied.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+		Expect(c.Patch(ctx, applied, client.Apply, client.FieldOwner("my-manager"))).To(Succeed())
+
+		Expect(c).To(HaveOperationOnce(OperationVerbs(VerbApply), OperationObject(cmRef)))
+		Expect(c.Operations(OperationVerbs(VerbPatch))).To(BeEmpty())
+		Expect(c.Operations()[0].PatchType).To(Equal(types.ApplyPatchType))
+	})
+
+	It("should record subresource operations", func() {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}
+		Expect(c.Create(ctx, pod)).To(Succeed())
+		pod.Status.Phase = corev1.PodRunning
+		Expect(c.Status().Update(ctx, pod)).To(Succeed())
+
+		ops := c.Operations(OperationSubResource("status"))
+		Expect(ops).To(HaveLen(1))
+		Expect(ops[0].Verb).To(Equal(VerbUpdate))
+		Expect(ops[0].String()).To(Equal("update status Pod/default/my-pod"))
+	})
+
+	It("should filter and reset the recorded operations", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(cm), cm)).To(Succeed())
+		Expect(c.Delete(ctx, cm)).To(Succeed())
+
+		Expect(c.Operations(OperationWrites())).To(HaveLen(2))
+		Expect(c.Operations(OperationVerbs(VerbDelete), OperationObject(cmRef))).To(HaveLen(1))
+		Expect(c.Operations(OperationNamespace("other"))).To(BeEmpty())
+
+		c.Reset()
+		Expect(c.Operations()).To(BeEmpty())
+	})
+
+	It("should be safe for concurrent use", func() {
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(c.List(ctx, &corev1.ConfigMapList{})).To(Succeed())
+			}()
+		}
+		wg.Wait()
+		Expect(c.Operations()).To(HaveLen(10))
+	})
+
+	It("should support gomega assertions on the recorded operations", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+		base := cm.DeepCopy()
+		cm.Data = map[string]string{"foo": "bar"}
+		Expect(c.Patch(ctx, cm, client.MergeFrom(base))).To(Succeed())
+
+		Expect(c).To(HaveOperationOnce(OperationVerbs(VerbPatch), OperationObject(cmRef)))
+		Expect(c).To(HaveNoOperations(OperationVerbs(VerbDelete, VerbDeleteAllOf), OperationNamespace(corev1.NamespaceDefault)))
+		Expect(c).To(HaveOperations(BeNumerically(">=", 2), OperationWrites()))
+		Expect(c).NotTo(HaveNoOperations(OperationKind(schema.GroupKind{Kind: "ConfigMap"})))
+	})
+})
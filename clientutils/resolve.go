@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObjectRefNoKindMatchError is returned when resolving an ObjectRef whose GroupKind is not known to the RESTMapper.
+// It wraps the original error, so meta.IsNoMatchError reports true for it.
+type ObjectRefNoKindMatchError struct {
+	// Ref is the ObjectRef that could not be resolved.
+	Ref ObjectRef
+	// Err is the original error of the RESTMapper.
+	Err error
+}
+
+// Error implements error.
+func (e *ObjectRefNoKindMatchError) Error() string {
+	return fmt.Sprintf("no kind match for %s: %v", e.Ref, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *ObjectRefNoKindMatchError) Unwrap() error {
+	return e.Err
+}
+
+// ObjectRefNotFoundError is returned when resolving an ObjectRef whose object does not exist.
+// It wraps the original error, so apierrors.IsNotFound reports true for it.
+type ObjectRefNotFoundError struct {
+	// Ref is the ObjectRef that could not be resolved.
+	Ref ObjectRef
+	// Err is the original error of the client.
+	Err error
+}
+
+// Error implements error.
+func (e *ObjectRefNotFoundError) Error() string {
+	return fmt.Sprintf("object %s not found: %v", e.Ref, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *ObjectRefNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// NewObjectForRef creates a new, empty object for the given ObjectRef with its key set.
+//
+// The preferred version of the GroupKind is determined using the given RESTMapper. If the scheme knows the
+// resulting group version kind, a typed object is returned, otherwise an *unstructured.Unstructured.
+// If the RESTMapper does not know the GroupKind, an *ObjectRefNoKindMatchError is returned.
+func NewObjectForRef(scheme *runtime.Scheme, mapper meta.RESTMapper, ref ObjectRef) (client.Object, error) {
+	mapping, err := mapper.RESTMapping(ref.GroupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, &ObjectRefNoKindMatchError{Ref: ref, Err: err}
+		}
+		return nil, fmt.Errorf("error getting rest mapping for %s: %w", ref.GroupKind, err)
+	}
+
+	obj, err := newObjectForGVK(scheme, mapping.GroupVersionKind)
+	if err != nil {
+		return nil, err
+	}
+	obj.SetNamespace(ref.Key.Namespace)
+	obj.SetName(ref.Key.Name)
+	return obj, nil
+}
+
+func newObjectForGVK(scheme *runtime.Scheme, gvk schema.GroupVersionKind) (client.Object, error) {
+	if scheme.Recognizes(gvk) {
+		runtimeObj, err := scheme.New(gvk)
+		if err != nil {
+			return nil, fmt.Errorf("error creating object for %s: %w", gvk, err)
+		}
+		if obj, ok := runtimeObj.(client.Object); ok {
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+			return obj, nil
+		}
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+// ResolveObjectRef fetches the object referenced by the given ObjectRef.
+//
+// The object is fetched in the preferred version of its GroupKind using the scheme and RESTMapper of the client,
+// see NewObjectForRef. If the RESTMapper does not know the GroupKind, an *ObjectRefNoKindMatchError is returned.
+// If the object does not exist, an *ObjectRefNotFoundError is returned.
+func ResolveObjectRef(ctx context.Context, c client.Client, ref ObjectRef) (client.Object, error) {
+	obj, err := NewObjectForRef(c.Scheme(), c.RESTMapper(), ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Get(ctx, ref.Key, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &ObjectRefNotFoundError{Ref: ref, Err: err}
+		}
+		return nil, fmt.Errorf("error getting %s: %w", ref, err)
+	}
+	return obj, nil
+}
+
+// ResolveObjectRefSet fetches the objects referenced by all ObjectRefs of the given set, see ResolveObjectRef.
+// It returns the fetched objects by their ObjectRef.
+//
+// The refs are processed in the order of ObjectRefSet.List. MaxConcurrency and ContinueOnError are respected,
+// KindOrder is ignored. With ContinueOnError, all objects that could be resolved are returned alongside a
+// *MultipleError whose individual errors wrap *ObjectRefNoKindMatchError and *ObjectRefNotFoundError as
+// appropriate.
+func ResolveObjectRefSet(ctx context.Context, c client.Client, s ObjectRefSet, opts ...MultipleOption) (map[ObjectRef]client.Object, error) {
+	o := &MultipleOptions{}
+	o.ApplyOptions(opts)
+	o.KindOrder = nil
+
+	// The batch objects are only placeholders, the actual objects are created once their kind is resolved.
+	refs := s.List()
+	objs := make([]client.Object, len(refs))
+	resolved := make([]client.Object, len(refs))
+	for i, ref := range refs {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(ref.GroupKind.WithVersion(""))
+		objs[i] = obj
+	}
+
+	err := o.run(ctx, c, batch{
+		verb: VerbGet,
+		objs: objs,
+		key: func(i int) client.ObjectKey {
+			return refs[i].Key
+		},
+		do: func(ctx context.Context, i int) error {
+			obj, err := ResolveObjectRef(ctx, c, refs[i])
+			if err != nil {
+				return err
+			}
+			resolved[i] = obj
+			return nil
+		},
+		wrap: func(_ int, err error) error {
+			return err
+		},
+	})
+
+	res := make(map[ObjectRef]client.Object, len(refs))
+	for i, obj := range resolved {
+		if obj != nil {
+			res[refs[i]] = obj
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Resolve", func() {
+	var (
+		ctx                                  context.Context
+		c                                    client.Client
+		cmRef, deployRef, missingRef, fooRef ObjectRef
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)).
+			WithObjects(
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-cm"}},
+				&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-deploy"}},
+			).
+			Build()
+
+		cmRef = ObjectRef{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Key: client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "my-cm"}}
+		deployRef = ObjectRef{GroupKind: schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"}, Key: client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "my-deploy"}}
+		missingRef = ObjectRef{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Key: client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "missing"}}
+		fooRef = ObjectRef{GroupKind: schema.GroupKind{Group: "example.org", Kind: "Foo"}, Key: client.ObjectKey{Name: "my-foo"}}
+	})
+
+	Describe("NewObjectForRef", func() {
+		It("should create a typed object if the scheme knows the kind", func() {
+			obj, err := NewObjectForRef(scheme.Scheme, c.RESTMapper(), deployRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj).To(BeAssignableToTypeOf(&appsv1.Deployment{}))
+			Expect(client.ObjectKeyFromObject(obj)).To(Equal(deployRef.Key))
+		})
+
+		It("should create an unstructured object in the preferred version otherwise", func() {
+			obj, err := NewObjectForRef(runtime.NewScheme(), c.RESTMapper(), deployRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj).To(BeAssignableToTypeOf(&unstructured.Unstructured{}))
+			Expect(obj.GetObjectKind().GroupVersionKind()).To(Equal(appsv1.SchemeGroupVersion.WithKind("Deployment")))
+		})
+	})
+
+	Describe("ResolveObjectRef", func() {
+		It("should fetch the referenced object", func() {
+			obj, err := ResolveObjectRef(ctx, c, cmRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj).To(BeAssignableToTypeOf(&corev1.ConfigMap{}))
+			Expect(obj.GetResourceVersion()).NotTo(BeEmpty())
+		})
+
+		It("should report objects that do not exist", func() {
+			_, err := ResolveObjectRef(ctx, c, missingRef)
+			var notFoundErr *ObjectRefNotFoundError
+			Expect(errors.As(err, &notFoundErr)).To(BeTrue())
+			Expect(notFoundErr.Ref).To(Equal(missingRef))
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should report kinds unknown to the rest mapper", func() {
+			_, err := ResolveObjectRef(ctx, c, fooRef)
+			var noKindMatchErr *ObjectRefNoKindMatchError
+			Expect(errors.As(err, &noKindMatchErr)).To(BeTrue())
+			Expect(noKindMatchErr.Ref).To(Equal(fooRef))
+			Expect(meta.IsNoMatchError(err)).To(BeTrue())
+		})
+	})
+
+	Describe("ResolveObjectRefSet", func() {
+		It("should fetch all referenced objects", func() {
+			res, err := ResolveObjectRefSet(ctx, c, NewObjectRefSet(cmRef, deployRef), MaxConcurrency(2))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(2))
+			Expect(res[cmRef]).To(BeAssignableToTypeOf(&corev1.ConfigMap{}))
+			Expect(res[deployRef]).To(BeAssignableToTypeOf(&appsv1.Deployment{}))
+		})
+
+		It("should report all failures and return the resolved objects with ContinueOnError", func() {
+			res, err := ResolveObjectRefSet(ctx, c, NewObjectRefSet(cmRef, missingRef, fooRef), ContinueOnError{})
+			Expect(res).To(HaveLen(1))
+			Expect(res).To(HaveKey(cmRef))
+
+			var multiErr *MultipleError
+			Expect(errors.As(err, &multiErr)).To(BeTrue())
+			Expect(multiErr.Failed).To(HaveLen(2))
+
+			var notFoundErr *ObjectRefNotFoundError
+			Expect(errors.As(multiErr.Failed[0], &notFoundErr)).To(BeTrue())
+			Expect(notFoundErr.Ref).To(Equal(missingRef))
+			var noKindMatchErr *ObjectRefNoKindMatchError
+			Expect(errors.As(multiErr.Failed[1], &noKindMatchErr)).To(BeTrue())
+			Expect(noKindMatchErr.Ref).To(Equal(fooRef))
+		})
+
+		It("should stop at the first failure by default", func() {
+			_, err := ResolveObjectRefSet(ctx, c, NewObjectRefSet(cmRef, missingRef, fooRef))
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
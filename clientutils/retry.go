@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RetryOptions are options for UpdateWithRetry, PatchWithRetry and their status variants.
+type RetryOptions struct {
+	// Backoff is the backoff between attempts. If nil, retry.DefaultRetry is used.
+	Backoff *wait.Backoff
+	// OptimisticLock makes patches fail with a conflict if the object was modified concurrently.
+	// It has no effect on updates, which always use optimistic locking, and on objects without
+	// resource version, e.g. because they were never fetched.
+	OptimisticLock bool
+}
+
+// ApplyToRetry implements RetryOption.
+func (o *RetryOptions) ApplyToRetry(o2 *RetryOptions) {
+	if o.Backoff != nil {
+		o2.Backoff = o.Backoff
+	}
+	if o.OptimisticLock {
+		o2.OptimisticLock = true
+	}
+}
+
+// ApplyOptions applies all RetryOption to this RetryOptions.
+func (o *RetryOptions) ApplyOptions(opts []RetryOption) {
+	for _, opt := range opts {
+		opt.ApplyToRetry(o)
+	}
+}
+
+// RetryOption is an option to UpdateWithRetry, PatchWithRetry and their status variants.
+type RetryOption interface {
+	// ApplyToRetry modifies the underlying RetryOptions.
+	ApplyToRetry(o *RetryOptions)
+}
+
+// RetryBackoff sets RetryOptions.Backoff.
+type RetryBackoff wait.Backoff
+
+// ApplyToRetry implements RetryOption.
+func (b RetryBackoff) ApplyToRetry(o *RetryOptions) {
+	backoff := wait.Backoff(b)
+	o.Backoff = &backoff
+}
+
+// OptimisticLock sets RetryOptions.OptimisticLock.
+type OptimisticLock struct{}
+
+// ApplyToRetry implements RetryOption.
+func (OptimisticLock) ApplyToRetry(o *RetryOptions) {
+	o.OptimisticLock = true
+}
+
+func (o *RetryOptions) mergeFrom(base client.Object) client.Patch {
+	if o.OptimisticLock && base.GetResourceVersion() != "" {
+		return client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})
+	}
+	return client.MergeFrom(base)
+}
+
+// retryOnConflict calls mutateFunc and do until do does not return a conflict error or the backoff is exhausted.
+// The first attempt uses obj as-is, subsequent attempts re-fetch obj before calling mutateFunc again.
+func (o *RetryOptions) retryOnConflict(
+	ctx context.Context,
+	c client.Reader,
+	obj client.Object,
+	mutateFunc func() error,
+	do func(base client.Object) error,
+) error {
+	backoff := retry.DefaultRetry
+	if o.Backoff != nil {
+		backoff = *o.Backoff
+	}
+
+	key := client.ObjectKeyFromObject(obj)
+	first := true
+	return retry.RetryOnConflict(backoff, func() error {
+		if !first {
+			if err := c.Get(ctx, key, obj); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		base := obj.DeepCopyObject().(client.Object)
+		if err := mutateFunc(); err != nil {
+			return err
+		}
+		return do(base)
+	})
+}
+
+// UpdateWithRetry calls mutateFunc and updates obj. If the update fails with a conflict, obj is re-fetched
+// and mutateFunc is called again, until the update succeeds or the backoff is exhausted.
+// mutateFunc has to modify obj and may be called multiple times.
+func UpdateWithRetry(ctx context.Context, c client.Client, obj client.Object, mutateFunc func() error, opts ...RetryOption) error {
+	o := &RetryOptions{}
+	o.ApplyOptions(opts)
+	return o.retryOnConflict(ctx, c, obj, mutateFunc, func(client.Object) error {
+		return c.Update(ctx, obj)
+	})
+}
+
+// UpdateStatusWithRetry is like UpdateWithRetry but updates the status of obj.
+func UpdateStatusWithRetry(ctx context.Context, c client.Client, obj client.Object, mutateFunc func() error, opts ...RetryOption) error {
+	o := &RetryOptions{}
+	o.ApplyOptions(opts)
+	return o.retryOnConflict(ctx, c, obj, mutateFunc, func(client.Object) error {
+		return c.Status().Update(ctx, obj)
+	})
+}
+
+// PatchWithRetry calls mutateFunc and patches obj with a merge patch computed from the changes of mutateFunc.
+// If the patch fails with a conflict, obj is re-fetched and mutateFunc is called again, until the patch succeeds
+// or the backoff is exhausted. mutateFunc has to modify obj and may be called multiple times.
+// Use OptimisticLock to make the patch fail with a conflict if obj was modified concurrently.
+func PatchWithRetry(ctx context.Context, c client.Client, obj client.Object, mutateFunc func() error, opts ...RetryOption) error {
+	o := &RetryOptions{}
+	o.ApplyOptions(opts)
+	return o.retryOnConflict(ctx, c, obj, mutateFunc, func(base client.Object) error {
+		return c.Patch(ctx, obj, o.mergeFrom(base))
+	})
+}
+
+// PatchStatusWithRetry is like PatchWithRetry but patches the status of obj.
+func PatchStatusWithRetry(ctx context.Context, c client.Client, obj client.Object, mutateFunc func() error, opts ...RetryOption) error {
+	o := &RetryOptions{}
+	o.ApplyOptions(opts)
+	return o.retryOnConflict(ctx, c, obj, mutateFunc, func(base client.Object) error {
+		return c.Status().Patch(ctx, obj, o.mergeFrom(base))
+	})
+}
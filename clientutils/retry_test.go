@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Retry", func() {
+	var (
+		ctx   context.Context
+		c     client.Client
+		pod   *corev1.Pod
+		calls int
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		calls = 0
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}).
+			WithStatusSubresource(&corev1.Pod{}).
+			Build()
+
+		pod = &corev1.Pod{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "my-pod"}, pod)).To(Succeed())
+
+		By("modifying the pod concurrently")
+		concurrent := pod.DeepCopy()
+		concurrent.Labels = map[string]string{"concurrent": "true"}
+		Expect(c.Update(ctx, concurrent)).To(Succeed())
+		concurrent.Status.Message = "concurrent"
+		Expect(c.Status().Update(ctx, concurrent)).To(Succeed())
+	})
+
+	mutate := func() error {
+		calls++
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations["mutated"] = "true"
+		pod.Status.Reason = "mutated"
+		return nil
+	}
+
+	expectRetried := func() {
+		Expect(calls).To(Equal(2))
+		actual := &corev1.Pod{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(pod), actual)).To(Succeed())
+		Expect(actual.Labels).To(HaveKeyWithValue("concurrent", "true"))
+		Expect(actual.Status.Message).To(Equal("concurrent"))
+	}
+
+	It("should re-fetch and retry updating on conflict", func() {
+		Expect(UpdateWithRetry(ctx, c, pod, mutate)).To(Succeed())
+		expectRetried()
+		Expect(pod.Annotations).To(HaveKeyWithValue("mutated", "true"))
+	})
+
+	It("should re-fetch and retry updating the status on conflict", func() {
+		Expect(UpdateStatusWithRetry(ctx, c, pod, mutate)).To(Succeed())
+		expectRetried()
+		Expect(pod.Status.Reason).To(Equal("mutated"))
+	})
+
+	It("should re-fetch and retry patching with optimistic lock on conflict", func() {
+		Expect(PatchWithRetry(ctx, c, pod, mutate, OptimisticLock{})).To(Succeed())
+		expectRetried()
+		Expect(pod.Annotations).To(HaveKeyWithValue("mutated", "true"))
+	})
+
+	It("should re-fetch and retry patching the status with optimistic lock on conflict", func() {
+		Expect(PatchStatusWithRetry(ctx, c, pod, mutate, OptimisticLock{})).To(Succeed())
+		expectRetried()
+		Expect(pod.Status.Reason).To(Equal("mutated"))
+	})
+
+	It("should patch without retrying if no optimistic lock is used", func() {
+		Expect(PatchWithRetry(ctx, c, pod, mutate)).To(Succeed())
+		Expect(calls).To(Equal(1))
+		Expect(pod.Labels).To(HaveKeyWithValue("concurrent", "true"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("mutated", "true"))
+	})
+
+	It("should return the conflict once the backoff is exhausted", func() {
+		c = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, obj.GetName(), nil)
+			},
+		})
+		err := PatchWithRetry(ctx, c, pod, mutate, RetryBackoff{Steps: 3})
+		Expect(apierrors.IsConflict(err)).To(BeTrue())
+		Expect(calls).To(Equal(3))
+	})
+})
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"maps"
+	"slices"
+)
+
+// The functions below implement the set algebra shared by ObjectKeySet, ObjectRefSet and GetRequestSet.
+// They operate on the keys of the given maps; values are only carried along.
+
+// setUnion returns a new map containing all keys of s1 and s2.
+// If a key is present in both, the value of s1 is used.
+func setUnion[M ~map[K]V, K comparable, V any](s1, s2 M) M {
+	res := make(M, len(s1)+len(s2))
+	maps.Copy(res, s2)
+	maps.Copy(res, s1)
+	return res
+}
+
+// setIntersection returns a new map containing all keys of s1 that are also present in s2.
+func setIntersection[M ~map[K]V, K comparable, V any](s1, s2 M) M {
+	res := make(M)
+	for k, v := range s1 {
+		if _, ok := s2[k]; ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// setDifference returns a new map containing all keys of s1 that are not present in s2.
+func setDifference[M ~map[K]V, K comparable, V any](s1, s2 M) M {
+	res := make(M)
+	for k, v := range s1 {
+		if _, ok := s2[k]; !ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// setEqual reports whether s1 and s2 contain the same keys.
+func setEqual[M ~map[K]V, K comparable, V any](s1, s2 M) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for k := range s1 {
+		if _, ok := s2[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// setSortedKeys returns the keys of s sorted by cmp.
+func setSortedKeys[M ~map[K]V, K comparable, V any](s M, cmp func(a, b K) int) []K {
+	return slices.SortedFunc(maps.Keys(s), cmp)
+}
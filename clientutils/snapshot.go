@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/ironcore-dev/controller-utils/unstructuredutils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+)
+
+// SnapshotOptions are options for ExportSnapshot.
+type SnapshotOptions struct {
+	// Kinds are the kinds of objects to export, in the order they are exported. At least one kind is required.
+	Kinds []schema.GroupVersionKind
+	// Namespaces restricts the export of namespaced objects to the given namespaces.
+	// If empty, objects of all namespaces are exported.
+	Namespaces []string
+	// LabelSelectors restricts the export to objects matching any of the given selectors.
+	// If empty, objects are exported regardless of their labels.
+	LabelSelectors []labels.Selector
+	// IncludeStatus exports the status of the objects.
+	IncludeStatus bool
+}
+
+// ApplyToSnapshot implements SnapshotOption.
+func (o *SnapshotOptions) ApplyToSnapshot(o2 *SnapshotOptions) {
+	o2.Kinds = append(o2.Kinds, o.Kinds...)
+	o2.Namespaces = append(o2.Namespaces, o.Namespaces...)
+	o2.LabelSelectors = append(o2.LabelSelectors, o.LabelSelectors...)
+	if o.IncludeStatus {
+		o2.IncludeStatus = true
+	}
+}
+
+// ApplyOptions applies all SnapshotOption to this SnapshotOptions.
+func (o *SnapshotOptions) ApplyOptions(opts []SnapshotOption) {
+	for _, opt := range opts {
+		opt.ApplyToSnapshot(o)
+	}
+}
+
+// SnapshotOption is an option to ExportSnapshot.
+type SnapshotOption interface {
+	// ApplyToSnapshot modifies the underlying SnapshotOptions.
+	ApplyToSnapshot(o *SnapshotOptions)
+}
+
+// SnapshotKinds adds to SnapshotOptions.Kinds.
+type SnapshotKinds []schema.GroupVersionKind
+
+// ApplyToSnapshot implements SnapshotOption.
+func (k SnapshotKinds) ApplyToSnapshot(o *SnapshotOptions) {
+	o.Kinds = append(o.Kinds, k...)
+}
+
+// SnapshotNamespaces adds to SnapshotOptions.Namespaces.
+type SnapshotNamespaces []string
+
+// ApplyToSnapshot implements SnapshotOption.
+func (n SnapshotNamespaces) ApplyToSnapshot(o *SnapshotOptions) {
+	o.Namespaces = append(o.Namespaces, n...)
+}
+
+// SnapshotLabelSelectors adds to SnapshotOptions.LabelSelectors.
+type SnapshotLabelSelectors []labels.Selector
+
+// ApplyToSnapshot implements SnapshotOption.
+func (s SnapshotLabelSelectors) ApplyToSnapshot(o *SnapshotOptions) {
+	o.LabelSelectors = append(o.LabelSelectors, s...)
+}
+
+// IncludeStatus sets SnapshotOptions.IncludeStatus.
+type IncludeStatus struct{}
+
+// ApplyToSnapshot implements SnapshotOption.
+func (IncludeStatus) ApplyToSnapshot(o *SnapshotOptions) {
+	o.IncludeStatus = true
+}
+
+// stripServerFields strips the server-managed metadata fields (see serverMetadataFields) and, unless includeStatus
+// is set, the status from the given object. Owner references are stripped as well, since they reference owners
+// by uid, which would make the garbage collector delete restored objects in another cluster.
+func stripServerFields(obj *unstructured.Unstructured, includeStatus bool) {
+	removeServerMetadataFields(obj.Object)
+	unstructured.RemoveNestedField(obj.Object, "metadata", "ownerReferences")
+	if !includeStatus {
+		unstructured.RemoveNestedField(obj.Object, "status")
+	}
+}
+
+// ExportSnapshot lists all objects matching the SnapshotOptions as unstructured objects, stripped of all
+// server-set fields and, unless IncludeStatus is specified, of their status.
+//
+// Objects are returned in the order of SnapshotOptions.Kinds and, within a kind, sorted by their key.
+// Lists are paginated, see ListAll.
+func ExportSnapshot(ctx context.Context, c client.Client, opts ...SnapshotOption) ([]unstructured.Unstructured, error) {
+	o := &SnapshotOptions{}
+	o.ApplyOptions(opts)
+	if len(o.Kinds) == 0 {
+		return nil, fmt.Errorf("must specify at least one kind")
+	}
+
+	selectors := o.LabelSelectors
+	if len(selectors) == 0 {
+		selectors = []labels.Selector{labels.Everything()}
+	}
+
+	var res []unstructured.Unstructured
+	for _, gvk := range o.Kinds {
+		namespaced, err := apiutil.IsGVKNamespaced(gvk, c.RESTMapper())
+		if err != nil {
+			return nil, fmt.Errorf("error determining whether %s is namespaced: %w", gvk, err)
+		}
+
+		namespaces := o.Namespaces
+		if !namespaced || len(namespaces) == 0 {
+			namespaces = []string{""}
+		}
+
+		objs := make(map[client.ObjectKey]unstructured.Unstructured)
+		for _, namespace := range namespaces {
+			for _, sel := range selectors {
+				list := &unstructured.UnstructuredList{}
+				list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+				if err := ListAll(ctx, c, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: sel}); err != nil {
+					return nil, fmt.Errorf("error listing %s: %w", gvk, err)
+				}
+
+				for _, obj := range list.Items {
+					objs[client.ObjectKeyFromObject(&obj)] = obj
+				}
+			}
+		}
+
+		for _, key := range slices.SortedFunc(maps.Keys(objs), CompareObjectKeys) {
+			obj := objs[key]
+			obj.SetGroupVersionKind(gvk)
+			stripServerFields(&obj, o.IncludeStatus)
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+// WriteSnapshot writes the given objects to w as multi-document YAML that can be read using unstructuredutils.Read.
+func WriteSnapshot(w io.Writer, objs []unstructured.Unstructured) error {
+	var buf bytes.Buffer
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return fmt.Errorf("error marshalling object %s: %w", client.ObjectKeyFromObject(&obj), err)
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ExportSnapshotToFile exports a snapshot (see ExportSnapshot) and writes it to the given file (see WriteSnapshot).
+func ExportSnapshotToFile(ctx context.Context, c client.Client, filename string, opts ...SnapshotOption) ([]unstructured.Unstructured, error) {
+	objs, err := ExportSnapshot(ctx, c, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, objs); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("error writing snapshot: %w", err)
+	}
+	return objs, nil
+}
+
+// RemapNamespaces sets the namespace of all given objects whose namespace is a key of the mapping
+// to the corresponding value. Namespace objects whose name is a key of the mapping are renamed accordingly,
+// so a snapshot containing its own namespaces is restored into the mapped namespaces.
+// Other objects are left untouched.
+func RemapNamespaces(objs []unstructured.Unstructured, mapping map[string]string) {
+	for i := range objs {
+		obj := &objs[i]
+		if obj.GroupVersionKind().GroupKind() == (schema.GroupKind{Kind: "Namespace"}) {
+			if name, ok := mapping[obj.GetName()]; ok {
+				obj.SetName(name)
+			}
+			continue
+		}
+		if namespace, ok := mapping[obj.GetNamespace()]; ok && obj.GetNamespace() != "" {
+			obj.SetNamespace(namespace)
+		}
+	}
+}
+
+// RestoreSnapshot remaps the namespaces of the given objects (see RemapNamespaces) and creates them
+// (see CreateMultiple). Any MultipleOption given in opts controls how the objects are processed.
+func RestoreSnapshot(ctx context.Context, c client.Client, objs []unstructured.Unstructured, namespaces map[string]string, opts ...client.CreateOption) error {
+	RemapNamespaces(objs, namespaces)
+	return CreateMultiple(ctx, c, unstructuredutils.UnstructuredSliceToObjectSliceNoCopy(objs), opts...)
+}
+
+// RestoreSnapshotFromFile reads the given snapshot file and restores its objects, see RestoreSnapshot.
+func RestoreSnapshotFromFile(ctx context.Context, c client.Client, filename string, namespaces map[string]string, opts ...client.CreateOption) ([]unstructured.Unstructured, error) {
+	objs, err := unstructuredutils.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := RestoreSnapshot(ctx, c, objs, namespaces, opts...); err != nil {
+		return nil, err
+	}
+	return objs, nil
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	"github.com/ironcore-dev/controller-utils/unstructuredutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Snapshot", func() {
+	var (
+		ctx            context.Context
+		src, dst       client.Client
+		cmGVK, nsGVK   = corev1.SchemeGroupVersion.WithKind("ConfigMap"), corev1.SchemeGroupVersion.WithKind("Namespace")
+		newFakeClient  func(objs ...client.Object) client.Client
+		withAppLabel   = map[string]string{"app": "foo"}
+		ownerReference = metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "owner-uid", Controller: ptr.To(true)}
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		newFakeClient = func(objs ...client.Object) client.Client {
+			return fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme)).
+				WithObjects(objs...).
+				Build()
+		}
+		src = newFakeClient(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-1"}},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "cm-b", Labels: withAppLabel, UID: "cm-b-uid", OwnerReferences: []metav1.OwnerReference{ownerReference}},
+				Data:       map[string]string{"foo": "bar"},
+			},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "cm-a", Labels: withAppLabel}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "unlabeled"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-2", Name: "other-ns", Labels: withAppLabel}},
+		)
+		dst = newFakeClient()
+	})
+
+	Describe("ExportSnapshot", func() {
+		It("should export the matching objects without server fields", func() {
+			objs, err := ExportSnapshot(ctx, src,
+				SnapshotKinds{nsGVK, cmGVK},
+				SnapshotNamespaces{"ns-1"},
+				SnapshotLabelSelectors{labels.SelectorFromSet(withAppLabel), labels.SelectorFromSet(labels.Set{"app": "bar"})},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, obj := range objs {
+				names = append(names, obj.GetName())
+			}
+			Expect(names).To(Equal([]string{"cm-a", "cm-b"}))
+
+			Expect(objs[1].GetUID()).To(BeEmpty())
+			Expect(objs[1].GetResourceVersion()).To(BeEmpty())
+			Expect(objs[1].GetOwnerReferences()).To(BeEmpty())
+			Expect(objs[1].GroupVersionKind()).To(Equal(cmGVK))
+			Expect(objs[1].Object["data"]).To(Equal(map[string]any{"foo": "bar"}))
+		})
+
+		It("should export cluster-scoped objects regardless of the namespaces", func() {
+			objs, err := ExportSnapshot(ctx, src, SnapshotKinds{nsGVK}, SnapshotNamespaces{"ns-2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			Expect(objs[0].GetName()).To(Equal("ns-1"))
+			Expect(objs[0].Object).NotTo(HaveKey("status"))
+		})
+
+		It("should keep the status if requested", func() {
+			objs, err := ExportSnapshot(ctx, src, SnapshotKinds{nsGVK}, IncludeStatus{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(objs).To(HaveLen(1))
+			Expect(objs[0].Object).To(HaveKey("status"))
+		})
+
+		It("should require at least one kind", func() {
+			_, err := ExportSnapshot(ctx, src)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("WriteSnapshot and RestoreSnapshot", func() {
+		It("should write a snapshot that can be restored into another namespace", func() {
+			objs, err := ExportSnapshot(ctx, src, SnapshotKinds{cmGVK}, SnapshotNamespaces{"ns-1"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect(WriteSnapshot(&buf, objs)).To(Succeed())
+			read, err := unstructuredutils.Read(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read).To(Equal(objs))
+
+			Expect(RestoreSnapshot(ctx, dst, read, map[string]string{"ns-1": "restored"})).To(Succeed())
+			cm := &corev1.ConfigMap{}
+			Expect(dst.Get(ctx, client.ObjectKey{Namespace: "restored", Name: "cm-b"}, cm)).To(Succeed())
+			Expect(cm.Data).To(Equal(map[string]string{"foo": "bar"}))
+			Expect(cm.Labels).To(Equal(withAppLabel))
+		})
+
+		It("should remap the namespaces contained in a snapshot", func() {
+			objs, err := ExportSnapshot(ctx, src, SnapshotKinds{nsGVK, cmGVK}, SnapshotNamespaces{"ns-1"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(RestoreSnapshot(ctx, dst, objs, map[string]string{"ns-1": "restored"}, OrderByKind{})).To(Succeed())
+
+			nsList := &corev1.NamespaceList{}
+			Expect(dst.List(ctx, nsList)).To(Succeed())
+			Expect(nsList.Items).To(ConsistOf(HaveField("Name", "restored")))
+
+			cmList := &corev1.ConfigMapList{}
+			Expect(dst.List(ctx, cmList)).To(Succeed())
+			Expect(cmList.Items).NotTo(BeEmpty())
+			Expect(cmList.Items).To(HaveEach(HaveField("Namespace", "restored")))
+		})
+
+		It("should export to and restore from files", func() {
+			filename := filepath.Join(GinkgoT().TempDir(), "snapshot.yaml")
+			exported, err := ExportSnapshotToFile(ctx, src, filename, SnapshotKinds{nsGVK, cmGVK})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exported).To(HaveLen(5))
+
+			restored, err := RestoreSnapshotFromFile(ctx, dst, filename, nil, OrderByKind{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(HaveLen(5))
+
+			list := &corev1.ConfigMapList{}
+			Expect(dst.List(ctx, list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(4))
+		})
+	})
+})
@@ -0,0 +1,407 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ironcore-dev/controller-utils/metautils"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StampOptions are options for StampingClient.
+type StampOptions struct {
+	// Labels are the labels every written object has to carry.
+	Labels map[string]string
+	// Annotations are the annotations every written object has to carry.
+	Annotations map[string]string
+	// SelectLabels restricts every list to objects carrying Labels.
+	SelectLabels bool
+}
+
+// ApplyToStamp implements StampOption.
+func (o *StampOptions) ApplyToStamp(o2 *StampOptions) {
+	if o.Labels != nil {
+		if o2.Labels == nil {
+			o2.Labels = make(map[string]string, len(o.Labels))
+		}
+		maps.Copy(o2.Labels, o.Labels)
+	}
+	if o.Annotations != nil {
+		if o2.Annotations == nil {
+			o2.Annotations = make(map[string]string, len(o.Annotations))
+		}
+		maps.Copy(o2.Annotations, o.Annotations)
+	}
+	if o.SelectLabels {
+		o2.SelectLabels = true
+	}
+}
+
+// ApplyOptions applies all StampOption to this StampOptions.
+func (o *StampOptions) ApplyOptions(opts []StampOption) {
+	for _, opt := range opts {
+		opt.ApplyToStamp(o)
+	}
+}
+
+// StampOption is an option to StampingClient.
+type StampOption interface {
+	// ApplyToStamp modifies the underlying StampOptions.
+	ApplyToStamp(o *StampOptions)
+}
+
+// StampLabels adds to StampOptions.Labels.
+type StampLabels map[string]string
+
+// ApplyToStamp implements StampOption.
+func (l StampLabels) ApplyToStamp(o *StampOptions) {
+	(&StampOptions{Labels: l}).ApplyToStamp(o)
+}
+
+// StampAnnotations adds to StampOptions.Annotations.
+type StampAnnotations map[string]string
+
+// ApplyToStamp implements StampOption.
+func (a StampAnnotations) ApplyToStamp(o *StampOptions) {
+	(&StampOptions{Annotations: a}).ApplyToStamp(o)
+}
+
+// SelectStampedLabels sets StampOptions.SelectLabels.
+type SelectStampedLabels struct{}
+
+// ApplyToStamp implements StampOption.
+func (SelectStampedLabels) ApplyToStamp(o *StampOptions) {
+	o.SelectLabels = true
+}
+
+type stampingClient struct {
+	client.Client
+	labels       map[string]string
+	annotations  map[string]string
+	selectLabels bool
+}
+
+// StampingClient returns a client.Client that stamps the configured labels and annotations on every object it
+// creates, updates or patches. Writes that set a stamped label or annotation to a different value are refused.
+// Updates restamp a missing label or annotation, while patches removing one are refused.
+//
+// Merge and strategic merge patches always set the stamp, also if their data is not computed from the object
+// (e.g. client.RawPatch). JSON patches are only checked, their data is not modified. Server-side applies, both
+// via Apply and via Patch with client.Apply, are not modified but refused if they do not carry the stamped labels
+// and annotations. The object passed to a refused patch is not modified. With SelectStampedLabels, every list is restricted to objects carrying the stamped labels.
+func StampingClient(c client.Client, opts ...StampOption) client.Client {
+	o := &StampOptions{}
+	o.ApplyOptions(opts)
+	return &stampingClient{
+		Client:       c,
+		labels:       o.Labels,
+		annotations:  o.Annotations,
+		selectLabels: o.SelectLabels,
+	}
+}
+
+func checkStamped(what string, actual, expected map[string]string, requirePresent bool) error {
+	for key, value := range expected {
+		actualValue, ok := actual[key]
+		if !ok {
+			if requirePresent {
+				return fmt.Errorf("%s %s=%s is missing", what, key, value)
+			}
+			continue
+		}
+		if actualValue != value {
+			return fmt.Errorf("%s %s has to be %q but is %q", what, key, value, actualValue)
+		}
+	}
+	return nil
+}
+
+// stamp checks that obj does not conflict with the stamped labels and annotations and then sets them.
+func (c *stampingClient) stamp(obj client.Object) error {
+	if err := checkStamped("label", obj.GetLabels(), c.labels, false); err != nil {
+		return err
+	}
+	if err := checkStamped("annotation", obj.GetAnnotations(), c.annotations, false); err != nil {
+		return err
+	}
+	metautils.SetLabels(obj, c.labels)
+	metautils.SetAnnotations(obj, c.annotations)
+	return nil
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// checkPatch checks that the given patch does not change or remove any stamped label or annotation.
+func (c *stampingClient) checkPatch(obj client.Object, patch client.Patch) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return fmt.Errorf("error getting patch data: %w", err)
+	}
+
+	switch patch.Type() {
+	case types.MergePatchType, types.StrategicMergePatchType:
+		var content struct {
+			Metadata map[string]json.RawMessage `json:"metadata"`
+		}
+		if err := json.Unmarshal(data, &content); err != nil {
+			return fmt.Errorf("error decoding patch: %w", err)
+		}
+		if err := checkMergePatchField("label", content.Metadata["labels"], c.labels); err != nil {
+			return err
+		}
+		return checkMergePatchField("annotation", content.Metadata["annotations"], c.annotations)
+	case types.JSONPatchType:
+		var ops []jsonPatchOp
+		if err := json.Unmarshal(data, &ops); err != nil {
+			return fmt.Errorf("error decoding patch: %w", err)
+		}
+		for _, op := range ops {
+			if err := checkJSONPatchOp("label", "/metadata/labels", op, c.labels); err != nil {
+				return err
+			}
+			if err := checkJSONPatchOp("annotation", "/metadata/annotations", op, c.annotations); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkMergePatchField(what string, raw json.RawMessage, expected map[string]string) error {
+	if raw == nil || len(expected) == 0 {
+		return nil
+	}
+
+	var values map[string]*string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return fmt.Errorf("error decoding patch %ss: %w", what, err)
+	}
+	if values == nil {
+		return fmt.Errorf("patch removes all %ss", what)
+	}
+	for key, value := range values {
+		expectedValue, ok := expected[key]
+		if !ok {
+			continue
+		}
+		if value == nil {
+			return fmt.Errorf("patch removes %s %s", what, key)
+		}
+		if *value != expectedValue {
+			return fmt.Errorf("%s %s has to be %q but patch sets %q", what, key, expectedValue, *value)
+		}
+	}
+	return nil
+}
+
+// stampedJSONPatchTarget returns whether the given JSON patch path targets the whole field or a stamped key of it.
+func stampedJSONPatchTarget(fieldPath, path string, expected map[string]string) (key string, whole, ok bool) {
+	if path == fieldPath {
+		return "", true, true
+	}
+	escapedKey, found := strings.CutPrefix(path, fieldPath+"/")
+	if !found {
+		return "", false, false
+	}
+	key = strings.NewReplacer("~1", "/", "~0", "~").Replace(escapedKey)
+	_, ok = expected[key]
+	return key, false, ok
+}
+
+func checkJSONPatchOp(what, fieldPath string, op jsonPatchOp, expected map[string]string) error {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	switch op.Op {
+	case "remove", "move":
+		path := op.Path
+		if op.Op == "move" {
+			path = op.From
+		}
+		key, whole, ok := stampedJSONPatchTarget(fieldPath, path, expected)
+		switch {
+		case !ok:
+			return nil
+		case whole:
+			return fmt.Errorf("patch removes all %ss", what)
+		default:
+			return fmt.Errorf("patch removes %s %s", what, key)
+		}
+	case "add", "replace":
+		key, whole, ok := stampedJSONPatchTarget(fieldPath, op.Path, expected)
+		if !ok {
+			return nil
+		}
+		if whole {
+			var values map[string]string
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return fmt.Errorf("error decoding patch %ss: %w", what, err)
+			}
+			if err := checkStamped(what, values, expected, true); err != nil {
+				return fmt.Errorf("patch replaces %ss: %w", what, err)
+			}
+			return nil
+		}
+
+		var value string
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return fmt.Errorf("error decoding patch %s %s: %w", what, key, err)
+		}
+		if value != expected[key] {
+			return fmt.Errorf("%s %s has to be %q but patch sets %q", what, key, expected[key], value)
+		}
+	}
+	return nil
+}
+
+func (c *stampingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if !c.selectLabels || len(c.labels) == 0 {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	o := &client.ListOptions{}
+	o.ApplyOptions(opts)
+	sel := o.LabelSelector
+	if sel == nil {
+		sel = labels.Everything()
+	}
+	for key, value := range c.labels {
+		req, err := labels.NewRequirement(key, selection.Equals, []string{value})
+		if err != nil {
+			return fmt.Errorf("error creating requirement for label %s: %w", key, err)
+		}
+		sel = sel.Add(*req)
+	}
+	return c.Client.List(ctx, list, append(slices.Clip(opts), client.MatchingLabelsSelector{Selector: sel})...)
+}
+
+func (c *stampingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.stamp(obj); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *stampingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.stamp(obj); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *stampingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	stampedPatch, err := c.stampPatch(obj, patch)
+	if err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, stampedPatch, opts...)
+}
+
+// stampPatch checks the given patch and returns a patch that additionally sets the stamped labels and annotations.
+// The patch is checked before stamping, so patches computed from obj (e.g. client.MergeFrom) removing a stamped
+// label or annotation are refused. The patch data is computed from a stamped copy of obj, obj itself is not modified.
+// Server-side apply patches are returned as they are if obj carries the stamp.
+func (c *stampingClient) stampPatch(obj client.Object, patch client.Patch) (client.Patch, error) {
+	if patch.Type() == types.ApplyPatchType {
+		// Like Apply, server-side applies are not modified but have to carry the stamp.
+		if err := checkStamped("label", obj.GetLabels(), c.labels, true); err != nil {
+			return nil, err
+		}
+		if err := checkStamped("annotation", obj.GetAnnotations(), c.annotations, true); err != nil {
+			return nil, err
+		}
+		return patch, nil
+	}
+
+	if err := checkStamped("label", obj.GetLabels(), c.labels, false); err != nil {
+		return nil, err
+	}
+	if err := checkStamped("annotation", obj.GetAnnotations(), c.annotations, false); err != nil {
+		return nil, err
+	}
+	if err := c.checkPatch(obj, patch); err != nil {
+		return nil, err
+	}
+
+	stamped := obj.DeepCopyObject().(client.Object)
+	metautils.SetLabels(stamped, c.labels)
+	metautils.SetAnnotations(stamped, c.annotations)
+	data, err := patch.Data(stamped)
+	if err != nil {
+		return nil, fmt.Errorf("error getting patch data: %w", err)
+	}
+
+	switch patch.Type() {
+	case types.MergePatchType, types.StrategicMergePatchType:
+		// Patches not computed from obj (e.g. client.RawPatch) do not contain the stamp yet.
+		if data, err = c.injectStamp(data); err != nil {
+			return nil, err
+		}
+	}
+	return client.RawPatch(patch.Type(), data), nil
+}
+
+// injectStamp sets the stamped labels and annotations in the given merge or strategic merge patch.
+func (c *stampingClient) injectStamp(data []byte) ([]byte, error) {
+	if len(c.labels) == 0 && len(c.annotations) == 0 {
+		return data, nil
+	}
+
+	var content map[string]any
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("error decoding patch: %w", err)
+	}
+	if content == nil {
+		content = make(map[string]any)
+	}
+	metadata, _ := content["metadata"].(map[string]any)
+	if metadata == nil {
+		metadata = make(map[string]any)
+		content["metadata"] = metadata
+	}
+	for field, values := range map[string]map[string]string{"labels": c.labels, "annotations": c.annotations} {
+		if len(values) == 0 {
+			continue
+		}
+		fieldValues, _ := metadata[field].(map[string]any)
+		if fieldValues == nil {
+			fieldValues = make(map[string]any, len(values))
+			metadata[field] = fieldValues
+		}
+		for key, value := range values {
+			fieldValues[key] = value
+		}
+	}
+	return json.Marshal(content)
+}
+
+func (c *stampingClient) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	u := applyConfigurationObject(obj)
+	if u == nil {
+		return fmt.Errorf("error converting apply configuration %T to unstructured", obj)
+	}
+	if err := checkStamped("label", u.GetLabels(), c.labels, true); err != nil {
+		return err
+	}
+	if err := checkStamped("annotation", u.GetAnnotations(), c.annotations, true); err != nil {
+		return err
+	}
+	return c.Client.Apply(ctx, obj, opts...)
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("StampingClient", func() {
+	var (
+		ctx   context.Context
+		fakeC client.Client
+		c     client.Client
+		cm    *corev1.ConfigMap
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-cm"}}
+		fakeC = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "unstamped"}}).
+			Build()
+		c = StampingClient(fakeC,
+			StampLabels{"app.kubernetes.io/managed-by": "my-operator"},
+			StampAnnotations{"example.org/tenant": "tenant-a"},
+		)
+	})
+
+	It("should stamp created objects", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+
+		actual := &corev1.ConfigMap{}
+		Expect(fakeC.Get(ctx, client.ObjectKeyFromObject(cm), actual)).To(Succeed())
+		Expect(actual.Labels).To(Equal(map[string]string{"app.kubernetes.io/managed-by": "my-operator"}))
+		Expect(actual.Annotations).To(Equal(map[string]string{"example.org/tenant": "tenant-a"}))
+	})
+
+	It("should restamp updated objects", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+
+		cm.Labels = nil
+		Expect(c.Update(ctx, cm)).To(Succeed())
+		Expect(cm.Labels).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "my-operator"))
+	})
+
+	It("should stamp patched objects", func() {
+		unstamped := &corev1.ConfigMap{}
+		Expect(fakeC.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "unstamped"}, unstamped)).To(Succeed())
+
+		base := unstamped.DeepCopy()
+		unstamped.Data = map[string]string{"foo": "bar"}
+		Expect(c.Patch(ctx, unstamped, client.MergeFrom(base))).To(Succeed())
+		Expect(unstamped.Labels).To(Equal(map[string]string{"app.kubernetes.io/managed-by": "my-operator"}))
+		Expect(unstamped.Annotations).To(Equal(map[string]string{"example.org/tenant": "tenant-a"}))
+		Expect(unstamped.Data).To(Equal(map[string]string{"foo": "bar"}))
+	})
+
+	It("should inject the stamp into raw merge patches", func() {
+		unstamped := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "unstamped"}}
+		Expect(c.Patch(ctx, unstamped, client.RawPatch(types.MergePatchType, []byte(`{"data":{"foo":"bar"}}`)))).To(Succeed())
+
+		actual := &corev1.ConfigMap{}
+		Expect(fakeC.Get(ctx, client.ObjectKeyFromObject(unstamped), actual)).To(Succeed())
+		Expect(actual.Labels).To(Equal(map[string]string{"app.kubernetes.io/managed-by": "my-operator"}))
+		Expect(actual.Annotations).To(Equal(map[string]string{"example.org/tenant": "tenant-a"}))
+		Expect(actual.Data).To(Equal(map[string]string{"foo": "bar"}))
+	})
+
+	It("should refuse merge patches removing a stamped value without modifying the object", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+
+		base := cm.DeepCopy()
+		cm.Annotations = nil
+		Expect(c.Patch(ctx, cm, client.MergeFrom(base))).To(MatchError("patch removes all annotations"))
+		Expect(cm.Annotations).To(BeNil())
+		Expect(cm.Labels).To(Equal(map[string]string{"app.kubernetes.io/managed-by": "my-operator"}))
+
+		actual := &corev1.ConfigMap{}
+		Expect(fakeC.Get(ctx, client.ObjectKeyFromObject(cm), actual)).To(Succeed())
+		Expect(actual.Annotations).To(Equal(map[string]string{"example.org/tenant": "tenant-a"}))
+	})
+
+	It("should not modify the object of a refused patch", func() {
+		unstamped := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "unstamped"}}
+		Expect(c.Patch(ctx, unstamped, client.RawPatch(types.MergePatchType,
+			[]byte(`{"metadata":{"labels":{"app.kubernetes.io/managed-by":"someone-else"}}}`),
+		))).To(HaveOccurred())
+		Expect(unstamped.Labels).To(BeNil())
+		Expect(unstamped.Annotations).To(BeNil())
+	})
+
+	It("should refuse server-side applies via patch that do not carry the stamp without modifying them", func() {
+		newCM := func() *corev1.ConfigMap {
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "applied"}}
+			cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+			return cm
+		}
+
+		unstamped := newCM()
+		Expect(c.Patch(ctx, unstamped, client.Apply, client.FieldOwner("my-manager"))).To(MatchError("label app.kubernetes.io/managed-by=my-operator is missing"))
+		Expect(unstamped.Labels).To(BeNil())
+
+		stamped := newCM()
+		stamped.Labels = map[string]string{"app.kubernetes.io/managed-by": "my-operator"}
+		stamped.Annotations = map[string]string{"example.org/tenant": "tenant-a"}
+		Expect(c.Patch(ctx, stamped, client.Apply, client.FieldOwner("my-manager"))).To(Succeed())
+	})
+
+	It("should refuse writes changing a stamped value", func() {
+		cm.Labels = map[string]string{"app.kubernetes.io/managed-by": "someone-else"}
+		Expect(c.Create(ctx, cm)).To(MatchError(`label app.kubernetes.io/managed-by has to be "my-operator" but is "someone-else"`))
+	})
+
+	It("should refuse patches removing stamped labels or annotations", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+
+		Expect(c.Patch(ctx, cm, client.RawPatch(types.MergePatchType,
+			[]byte(`{"metadata":{"labels":{"app.kubernetes.io/managed-by":null}}}`),
+		))).To(MatchError("patch removes label app.kubernetes.io/managed-by"))
+		Expect(c.Patch(ctx, cm, client.RawPatch(types.MergePatchType,
+			[]byte(`{"metadata":{"annotations":null}}`),
+		))).To(MatchError("patch removes all annotations"))
+		Expect(c.Patch(ctx, cm, client.RawPatch(types.JSONPatchType,
+			[]byte(`[{"op":"remove","path":"/metadata/labels/app.kubernetes.io~1managed-by"}]`),
+		))).To(MatchError("patch removes label app.kubernetes.io/managed-by"))
+
+		Expect(c.Patch(ctx, cm, client.RawPatch(types.MergePatchType,
+			[]byte(`{"metadata":{"labels":{"other":"value"}}}`),
+		))).To(Succeed())
+	})
+
+	It("should restrict lists to stamped objects if configured", func() {
+		Expect(c.Create(ctx, cm)).To(Succeed())
+
+		list := &corev1.ConfigMapList{}
+		Expect(c.List(ctx, list)).To(Succeed())
+		Expect(list.Items).To(HaveLen(2))
+
+		c = StampingClient(fakeC, StampLabels{"app.kubernetes.io/managed-by": "my-operator"}, SelectStampedLabels{})
+		Expect(c.List(ctx, list, client.InNamespace(corev1.NamespaceDefault))).To(Succeed())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].Name).To(Equal("my-cm"))
+
+		Expect(c.List(ctx, list, client.MatchingLabels{"other": "value"})).To(Succeed())
+		Expect(list.Items).To(BeEmpty())
+	})
+})
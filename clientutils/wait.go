@@ -0,0 +1,501 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/controller-utils/conditionutils"
+	"github.com/ironcore-dev/controller-utils/metautils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultWaitInterval is the default interval in which WaitFor polls objects.
+const DefaultWaitInterval = time.Second
+
+// Predicate is a predicate on an object that can be waited for.
+type Predicate interface {
+	// Satisfied reports whether the predicate holds for the given object.
+	// If exists is false, the object does not exist and obj only carries its key.
+	Satisfied(obj client.Object, exists bool) (bool, error)
+	// String describes the predicate.
+	String() string
+}
+
+type predicateFunc struct {
+	name string
+	f    func(obj client.Object, exists bool) (bool, error)
+}
+
+func (p predicateFunc) Satisfied(obj client.Object, exists bool) (bool, error) {
+	return p.f(obj, exists)
+}
+
+func (p predicateFunc) String() string {
+	return p.name
+}
+
+// NewPredicate creates a new Predicate with the given name from the given function.
+func NewPredicate(name string, f func(obj client.Object, exists bool) (bool, error)) Predicate {
+	return predicateFunc{name: name, f: f}
+}
+
+// Exists is satisfied if the object exists.
+var Exists = NewPredicate("exists", func(_ client.Object, exists bool) (bool, error) {
+	return exists, nil
+})
+
+// Deleted is satisfied if the object does not exist.
+var Deleted = NewPredicate("deleted", func(_ client.Object, exists bool) (bool, error) {
+	return !exists, nil
+})
+
+// AllPredicates is satisfied if all the given predicates are satisfied.
+func AllPredicates(preds ...Predicate) Predicate {
+	names := make([]string, 0, len(preds))
+	for _, pred := range preds {
+		names = append(names, pred.String())
+	}
+	return NewPredicate(strings.Join(names, " and "), func(obj client.Object, exists bool) (bool, error) {
+		for _, pred := range preds {
+			ok, err := pred.Satisfied(obj, exists)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+}
+
+// ConditionIs is satisfied if the object has a condition of the given type with the given status.
+// The conditions are read from the object's status.conditions. If acc is nil, conditionutils.DefaultAccessor is used.
+func ConditionIs(acc *conditionutils.Accessor, typ string, status corev1.ConditionStatus) Predicate {
+	if acc == nil {
+		acc = conditionutils.DefaultAccessor
+	}
+	return NewPredicate(fmt.Sprintf("condition %s is %s", typ, status), func(obj client.Object, exists bool) (bool, error) {
+		if !exists {
+			return false, nil
+		}
+
+		conditions, err := conditionsOf(obj)
+		if err != nil {
+			return false, err
+		}
+
+		idx, err := acc.FindSliceIndex(conditions, typ)
+		if err != nil || idx < 0 {
+			return false, err
+		}
+
+		actual, err := acc.Status(reflect.ValueOf(conditions).Index(idx).Interface())
+		if err != nil {
+			return false, err
+		}
+		return actual == status, nil
+	})
+}
+
+// ConditionCurrent is satisfied if the object has a condition of the given type whose observed generation
+// matches the object's generation. If acc is nil, conditionutils.DefaultAccessor is used.
+func ConditionCurrent(acc *conditionutils.Accessor, typ string) Predicate {
+	if acc == nil {
+		acc = conditionutils.DefaultAccessor
+	}
+	return NewPredicate(fmt.Sprintf("condition %s is current", typ), func(obj client.Object, exists bool) (bool, error) {
+		if !exists {
+			return false, nil
+		}
+
+		conditions, err := conditionsOf(obj)
+		if err != nil {
+			return false, err
+		}
+
+		idx, err := acc.FindSliceIndex(conditions, typ)
+		if err != nil || idx < 0 {
+			return false, err
+		}
+
+		observedGeneration, err := acc.ObservedGeneration(reflect.ValueOf(conditions).Index(idx).Interface())
+		if err != nil {
+			return false, err
+		}
+		return observedGeneration >= obj.GetGeneration(), nil
+	})
+}
+
+// ObservedGenerationCurrent is satisfied if the object's status.observedGeneration matches its generation.
+var ObservedGenerationCurrent = NewPredicate("observed generation is current", func(obj client.Object, exists bool) (bool, error) {
+	if !exists {
+		return false, nil
+	}
+
+	status, err := statusOf(obj)
+	if err != nil {
+		return false, err
+	}
+
+	observedGeneration, _, err := unstructured.NestedInt64(status, "observedGeneration")
+	if err != nil {
+		return false, fmt.Errorf("error getting observed generation: %w", err)
+	}
+	return observedGeneration >= obj.GetGeneration(), nil
+})
+
+// conditionsOf returns the conditions in status.conditions of the given object.
+// For unstructured objects, the conditions are returned as []metav1.Condition.
+func conditionsOf(obj client.Object) (interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		items, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+		if err != nil {
+			return nil, fmt.Errorf("error getting conditions: %w", err)
+		}
+
+		conditions := make([]metav1.Condition, len(items))
+		for i, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[condition %d]: expected object but got %T", i, item)
+			}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &conditions[i]); err != nil {
+				return nil, fmt.Errorf("[condition %d]: error converting condition: %w", i, err)
+			}
+		}
+		return conditions, nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("object %T is no pointer to a struct", obj)
+	}
+	status := v.FieldByName("Status")
+	if status.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("object %T has no status", obj)
+	}
+	conditions := status.FieldByName("Conditions")
+	if conditions.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("object %T has no status conditions", obj)
+	}
+	return conditions.Interface(), nil
+}
+
+// statusOf returns the status of the given object as unstructured map.
+func statusOf(obj client.Object) (map[string]interface{}, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error converting object to unstructured: %w", err)
+	}
+
+	status, _, err := unstructured.NestedMap(data, "status")
+	if err != nil {
+		return nil, fmt.Errorf("error getting status: %w", err)
+	}
+	return status, nil
+}
+
+// WaitOptions are options for WaitFor and WaitForMultiple.
+type WaitOptions struct {
+	// Timeout is the maximum duration to wait. If zero, WaitFor waits until the context is done.
+	Timeout time.Duration
+	// Interval is the interval in which objects are polled. If zero, DefaultWaitInterval is used.
+	Interval time.Duration
+	// NoWatch disables watching objects even if the client supports it.
+	NoWatch bool
+}
+
+// ApplyToWait implements WaitOption.
+func (o *WaitOptions) ApplyToWait(o2 *WaitOptions) {
+	if o.Timeout > 0 {
+		o2.Timeout = o.Timeout
+	}
+	if o.Interval > 0 {
+		o2.Interval = o.Interval
+	}
+	if o.NoWatch {
+		o2.NoWatch = true
+	}
+}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see DeleteIfExistsAndWait.
+func (o *WaitOptions) ApplyToDelete(*client.DeleteOptions) {}
+
+// ApplyOptions applies all WaitOption to this WaitOptions.
+func (o *WaitOptions) ApplyOptions(opts []WaitOption) {
+	for _, opt := range opts {
+		opt.ApplyToWait(o)
+	}
+}
+
+// WaitOption is an option to WaitFor and WaitForMultiple.
+//
+// To be usable with DeleteIfExistsAndWait and DeleteMultipleIfExistAndWait, implementations also implement
+// client.DeleteOption. These functions filter them out before calling the client.
+type WaitOption interface {
+	// ApplyToWait modifies the underlying WaitOptions.
+	ApplyToWait(o *WaitOptions)
+}
+
+// WaitTimeout sets WaitOptions.Timeout.
+type WaitTimeout time.Duration
+
+// ApplyToWait implements WaitOption.
+func (t WaitTimeout) ApplyToWait(o *WaitOptions) {
+	o.Timeout = time.Duration(t)
+}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see DeleteIfExistsAndWait.
+func (t WaitTimeout) ApplyToDelete(*client.DeleteOptions) {}
+
+// WaitInterval sets WaitOptions.Interval.
+type WaitInterval time.Duration
+
+// ApplyToWait implements WaitOption.
+func (i WaitInterval) ApplyToWait(o *WaitOptions) {
+	o.Interval = time.Duration(i)
+}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see DeleteIfExistsAndWait.
+func (i WaitInterval) ApplyToDelete(*client.DeleteOptions) {}
+
+// WaitNoWatch sets WaitOptions.NoWatch.
+type WaitNoWatch struct{}
+
+// ApplyToWait implements WaitOption.
+func (WaitNoWatch) ApplyToWait(o *WaitOptions) {
+	o.NoWatch = true
+}
+
+// ApplyToDelete implements client.DeleteOption. It is a no-op, see DeleteIfExistsAndWait.
+func (WaitNoWatch) ApplyToDelete(*client.DeleteOptions) {}
+
+// WaitError is returned by WaitFor if the predicate was not satisfied in time.
+type WaitError struct {
+	// Key is the key of the object.
+	Key client.ObjectKey
+	// Predicate is the predicate that was not satisfied.
+	Predicate string
+	// Last is the last observed state of the object. Only its key is set if it did not exist.
+	Last client.Object
+	// Exists reports whether the object existed when it was last observed.
+	Exists bool
+	// Err is the cause, usually context.DeadlineExceeded or context.Canceled.
+	Err error
+}
+
+// Error implements error.
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("error waiting for %s to satisfy %q (last observed: %s): %v", e.Key, e.Predicate, e.lastObserved(), e.Err)
+}
+
+func (e *WaitError) lastObserved() string {
+	if e.Last == nil {
+		return "never"
+	}
+	if !e.Exists {
+		return "not found"
+	}
+	status, err := statusOf(e.Last)
+	if err != nil || len(status) == 0 {
+		return "no status"
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return "no status"
+	}
+	return "status " + string(data)
+}
+
+// Unwrap returns the cause.
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// WaitFor waits until the given predicate is satisfied for the given object. obj is updated with the last observed
+// state of the object.
+//
+// If the client implements client.WithWatch, WaitFor watches the object, otherwise it polls the object in the
+// configured interval. If the predicate is not satisfied before the timeout or ctx is done, a *WaitError is returned.
+func WaitFor(ctx context.Context, c client.Client, obj client.Object, pred Predicate, opts ...WaitOption) error {
+	o := &WaitOptions{}
+	o.ApplyOptions(opts)
+	if o.Interval <= 0 {
+		o.Interval = DefaultWaitInterval
+	}
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	w := &waiter{c: c, obj: obj, key: client.ObjectKeyFromObject(obj), pred: pred}
+	err := w.wait(ctx, o)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	werr := &WaitError{Key: w.key, Predicate: pred.String(), Exists: w.exists, Err: err}
+	if w.observed {
+		werr.Last = obj
+	}
+	return werr
+}
+
+// WaitForMultiple waits until the given predicate is satisfied for all given objects using WaitFor.
+// All objects are waited for in parallel. If the predicate is not satisfied for any of the objects,
+// a *MultipleError reporting the *WaitError of all these objects is returned.
+func WaitForMultiple(ctx context.Context, c client.Client, objs []client.Object, pred Predicate, opts ...WaitOption) error {
+	o := &WaitOptions{}
+	o.ApplyOptions(opts)
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	mo := &MultipleOptions{MaxConcurrency: len(objs), ContinueOnError: true}
+	return mo.run(ctx, c, batch{
+		verb: VerbWait,
+		objs: objs,
+		do: func(ctx context.Context, i int) error {
+			return WaitFor(ctx, c, objs[i], pred, &WaitOptions{Interval: o.Interval, NoWatch: o.NoWatch})
+		},
+	})
+}
+
+type waiter struct {
+	c    client.Client
+	obj  client.Object
+	key  client.ObjectKey
+	pred Predicate
+
+	observed bool
+	exists   bool
+}
+
+func (w *waiter) wait(ctx context.Context, o *WaitOptions) error {
+	if wc, ok := w.c.(client.WithWatch); ok && !o.NoWatch {
+		done, err := w.watch(ctx, wc)
+		if err != nil || done {
+			return err
+		}
+	}
+	return wait.PollUntilContextCancel(ctx, o.Interval, true, func(ctx context.Context) (bool, error) {
+		return w.get(ctx)
+	})
+}
+
+// get gets the object and checks the predicate.
+func (w *waiter) get(ctx context.Context) (bool, error) {
+	if err := w.c.Get(ctx, w.key, w.obj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			return false, fmt.Errorf("error getting object %s: %w", w.key, err)
+		}
+		return w.check(false)
+	}
+	return w.check(true)
+}
+
+func (w *waiter) check(exists bool) (bool, error) {
+	w.observed = true
+	w.exists = exists
+	ok, err := w.pred.Satisfied(w.obj, exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking %q: %w", w.pred, err)
+	}
+	return ok, nil
+}
+
+// watch watches the object until the predicate is satisfied. If the object cannot be watched, it returns false
+// without error so the caller can fall back to polling.
+func (w *waiter) watch(ctx context.Context, c client.WithWatch) (bool, error) {
+	_, list, err := metautils.NewListForObject(c.Scheme(), w.obj)
+	if err != nil {
+		return false, nil
+	}
+
+	for {
+		if done, err := w.get(ctx); err != nil || done {
+			return done, err
+		}
+
+		// Start watching right after the observed state so no change in between is missed. If the object does
+		// not exist, the watch starts with the current state instead.
+		var resourceVersion string
+		if w.exists {
+			resourceVersion = w.obj.GetResourceVersion()
+		}
+		watcher, err := c.Watch(ctx, list,
+			client.InNamespace(w.key.Namespace),
+			client.MatchingFieldsSelector{Selector: fields.OneTermEqualSelector("metadata.name", w.key.Name)},
+			&client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: resourceVersion}},
+		)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			return false, nil
+		}
+
+		done, err := w.handleEvents(ctx, watcher)
+		watcher.Stop()
+		if err != nil || done {
+			return done, err
+		}
+		// The watch was closed by the server, start over.
+	}
+}
+
+func (w *waiter) handleEvents(ctx context.Context, watcher watch.Interface) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, nil
+			}
+
+			obj, ok := event.Object.(client.Object)
+			if !ok || obj.GetName() != w.key.Name || obj.GetNamespace() != w.key.Namespace {
+				continue
+			}
+
+			var exists bool
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				exists = true
+			case watch.Deleted:
+			default:
+				continue
+			}
+			if err := setObject(w.obj, obj); err != nil {
+				return false, err
+			}
+			if done, err := w.check(exists); err != nil || done {
+				return done, err
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clientutils_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/ironcore-dev/controller-utils/clientutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Wait", func() {
+	var (
+		ctx context.Context
+		c   client.WithWatch
+		pod *corev1.Pod
+	)
+	BeforeEach(func() {
+		ctx = context.Background()
+		pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod", Generation: 1}}
+		c = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(pod.DeepCopy()).
+			WithStatusSubresource(&corev1.Pod{}).
+			Build()
+	})
+
+	setReady := func(status corev1.ConditionStatus) {
+		GinkgoHelper()
+		pod := &corev1.Pod{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceDefault, Name: "my-pod"}, pod)).To(Succeed())
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+		Expect(c.Status().Update(ctx, pod)).To(Succeed())
+	}
+
+	Describe("Predicates", func() {
+		It("should evaluate condition predicates on typed and unstructured objects", func() {
+			ready := ConditionIs(nil, string(corev1.PodReady), corev1.ConditionTrue)
+
+			ok, err := ready.Satisfied(pod, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+
+			pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+			Expect(ready.Satisfied(pod, true)).To(BeTrue())
+			Expect(ready.Satisfied(pod, false)).To(BeFalse())
+
+			u := &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(1),
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(2)},
+					},
+				},
+			}}
+			Expect(ready.Satisfied(u, true)).To(BeTrue())
+			Expect(ConditionCurrent(nil, "Ready").Satisfied(u, true)).To(BeTrue())
+			Expect(ObservedGenerationCurrent.Satisfied(u, true)).To(BeFalse())
+			Expect(AllPredicates(Exists, ready).Satisfied(u, true)).To(BeTrue())
+			Expect(AllPredicates(Exists, ready).String()).To(Equal("exists and condition Ready is True"))
+		})
+	})
+
+	Describe("WaitFor", func() {
+		It("should watch the object until the predicate is satisfied", func() {
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(50 * time.Millisecond)
+				setReady(corev1.ConditionTrue)
+			}()
+
+			obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}
+			Expect(WaitFor(ctx, c, obj, ConditionIs(nil, string(corev1.PodReady), corev1.ConditionTrue), WaitTimeout(5*time.Second))).To(Succeed())
+			Expect(obj.Status.Conditions).To(ConsistOf(HaveField("Status", corev1.ConditionTrue)))
+		})
+
+		It("should start watching at the resource version of the observed object", func() {
+			var resourceVersions []string
+			c = interceptor.NewClient(c, interceptor.Funcs{
+				Watch: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+					resourceVersions = append(resourceVersions, (&client.ListOptions{}).ApplyOptions(opts).AsListOptions().ResourceVersion)
+					return c.Watch(ctx, list, opts...)
+				},
+			})
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(50 * time.Millisecond)
+				setReady(corev1.ConditionTrue)
+			}()
+
+			obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}
+			Expect(WaitFor(ctx, c, obj, ConditionIs(nil, string(corev1.PodReady), corev1.ConditionTrue), WaitTimeout(5*time.Second))).To(Succeed())
+			Expect(resourceVersions).To(Equal([]string{"999"}))
+		})
+
+		It("should poll the object until it is deleted", func() {
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(50 * time.Millisecond)
+				Expect(c.Delete(ctx, pod)).To(Succeed())
+			}()
+
+			obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}
+			Expect(WaitFor(ctx, c, obj, Deleted, WaitNoWatch{}, WaitInterval(10*time.Millisecond), WaitTimeout(5*time.Second))).To(Succeed())
+		})
+
+		It("should report the unmet predicate and the last observed state on timeout", func() {
+			setReady(corev1.ConditionFalse)
+
+			obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}
+			err := WaitFor(ctx, c, obj, ConditionIs(nil, string(corev1.PodReady), corev1.ConditionTrue), WaitTimeout(50*time.Millisecond))
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+			var waitErr *WaitError
+			Expect(errors.As(err, &waitErr)).To(BeTrue())
+			Expect(waitErr.Predicate).To(Equal("condition Ready is True"))
+			Expect(waitErr.Exists).To(BeTrue())
+			Expect(waitErr.Last).To(BeIdenticalTo(obj))
+			Expect(err).To(MatchError(ContainSubstring(`"status":"False"`)))
+		})
+
+		It("should stop once the context is done", func() {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}}
+			Expect(WaitFor(ctx, c, obj, Deleted)).To(MatchError(context.Canceled))
+		})
+	})
+
+	Describe("WaitForMultiple", func() {
+		It("should report all objects that did not satisfy the predicate", func() {
+			objs := []client.Object{
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "my-pod"}},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: corev1.NamespaceDefault, Name: "other-pod"}},
+			}
+
+			err := WaitForMultiple(ctx, c, objs, Exists, WaitTimeout(100*time.Millisecond), WaitInterval(10*time.Millisecond))
+
+			var multiErr *MultipleError
+			Expect(errors.As(err, &multiErr)).To(BeTrue())
+			Expect(multiErr.Succeeded).To(Equal([]int{0}))
+			Expect(multiErr.Failed).To(ConsistOf(HaveField("Verb", VerbWait)))
+
+			var waitErr *WaitError
+			Expect(errors.As(multiErr.Failed[0], &waitErr)).To(BeTrue())
+			Expect(waitErr.Key.Name).To(Equal("other-pod"))
+			Expect(waitErr.Exists).To(BeFalse())
+		})
+	})
+})
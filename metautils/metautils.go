@@ -5,7 +5,9 @@
 package metautils
 
 import (
+	"errors"
 	"fmt"
+	"iter"
 	"reflect"
 	"strings"
 
@@ -348,6 +350,43 @@ func EachListItem(list client.ObjectList, f func(obj client.Object) error) error
 	})
 }
 
+// errStopIteration is used to stop meta.EachListItem once the consumer of an iterator stops.
+var errStopIteration = errors.New("stop iteration")
+
+// ListItems returns an iterator over all items of the client.ObjectList.
+// If the list cannot be traversed or an item does not implement client.Object, the error is yielded
+// and the iteration ends.
+func ListItems(list client.ObjectList) iter.Seq2[client.Object, error] {
+	return func(yield func(client.Object, error) bool) {
+		if err := EachListItem(list, func(obj client.Object) error {
+			if !yield(obj, nil) {
+				return errStopIteration
+			}
+			return nil
+		}); err != nil && !errors.Is(err, errStopIteration) {
+			yield(nil, err)
+		}
+	}
+}
+
+// FilterItems returns an iterator over all objects of seq that match the given function.
+// Errors of seq are passed through.
+func FilterItems(seq iter.Seq2[client.Object, error], f func(obj client.Object) bool) iter.Seq2[client.Object, error] {
+	return func(yield func(client.Object, error) bool) {
+		for obj, err := range seq {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+				continue
+			}
+			if f(obj) && !yield(obj, nil) {
+				return
+			}
+		}
+	}
+}
+
 // FilterList filters the list with the given function, mutating it in-place with the filtered objects.
 func FilterList(list client.ObjectList, f func(obj client.Object) bool) error {
 	var filtered []client.Object
@@ -532,6 +532,60 @@ var _ = Describe("Metautils", func() {
 		})
 	})
 
+	Describe("ListItems", func() {
+		It("should iterate over each list item", func() {
+			list := &corev1.SecretList{
+				Items: []corev1.Secret{
+					{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "bar"}},
+				},
+			}
+
+			var objs []client.Object
+			for obj, err := range ListItems(list) {
+				Expect(err).NotTo(HaveOccurred())
+				objs = append(objs, obj)
+			}
+			Expect(objs).To(Equal([]client.Object{&list.Items[0], &list.Items[1]}))
+		})
+
+		It("should stop when the consumer stops", func() {
+			list := &corev1.SecretList{
+				Items: []corev1.Secret{
+					{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "bar"}},
+				},
+			}
+
+			var objs []client.Object
+			for obj := range ListItems(list) {
+				objs = append(objs, obj)
+				break
+			}
+			Expect(objs).To(Equal([]client.Object{&list.Items[0]}))
+		})
+	})
+
+	Describe("FilterItems", func() {
+		It("should only yield the matching items", func() {
+			list := &corev1.SecretList{
+				Items: []corev1.Secret{
+					{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "bar"}},
+				},
+			}
+
+			var objs []client.Object
+			for obj, err := range FilterItems(ListItems(list), func(obj client.Object) bool {
+				return obj.GetName() == "bar"
+			}) {
+				Expect(err).NotTo(HaveOccurred())
+				objs = append(objs, obj)
+			}
+			Expect(objs).To(Equal([]client.Object{&list.Items[1]}))
+		})
+	})
+
 	DescribeTable("HasLabel",
 		func(initLabels map[string]string, key string, expected bool) {
 			obj := &metav1.ObjectMeta{Labels: initLabels}
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"strings"
 
+	"github.com/ironcore-dev/controller-utils/clientutils"
 	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
 	"k8s.io/utils/semantic"
 )
 
@@ -113,3 +116,64 @@ func (m *ErrorFuncMatcher) NegatedFailureMessage(actual interface{}) (message st
 	name := m.nameOrFuncName()
 	return fmt.Sprintf("expected an error not matching %s to have occurred but got %s", name, format.Object(actual, 0))
 }
+
+// OperationsMatcher is a matcher that matches the number of operations selected by the given filters
+// against the Count matcher. The actual value has to be a *clientutils.RecordingClient or a slice of
+// clientutils.Operation.
+type OperationsMatcher struct {
+	Filters []clientutils.OperationFilter
+	Count   types.GomegaMatcher
+
+	operations []clientutils.Operation
+	selected   []clientutils.Operation
+}
+
+func (m *OperationsMatcher) Match(actual interface{}) (success bool, err error) {
+	if m.Count == nil {
+		return false, fmt.Errorf("must set Count")
+	}
+
+	switch actual := actual.(type) {
+	case *clientutils.RecordingClient:
+		m.operations = actual.Operations()
+	case []clientutils.Operation:
+		m.operations = actual
+	default:
+		return false, fmt.Errorf("expected a *clientutils.RecordingClient or []clientutils.Operation but got %s", format.Object(actual, 0))
+	}
+
+	m.selected = nil
+	for _, op := range m.operations {
+		if op.Matches(m.Filters...) {
+			m.selected = append(m.selected, op)
+		}
+	}
+	return m.Count.Match(len(m.selected))
+}
+
+func formatOperations(ops []clientutils.Operation) string {
+	if len(ops) == 0 {
+		return "    <none>"
+	}
+	lines := make([]string, len(ops))
+	for i, op := range ops {
+		lines[i] = "    " + op.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *OperationsMatcher) FailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("unexpected number of selected operations: %s\nselected operations:\n%s\nall operations:\n%s",
+		m.Count.FailureMessage(len(m.selected)),
+		formatOperations(m.selected),
+		formatOperations(m.operations),
+	)
+}
+
+func (m *OperationsMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("unexpected number of selected operations: %s\nselected operations:\n%s\nall operations:\n%s",
+		m.Count.NegatedFailureMessage(len(m.selected)),
+		formatOperations(m.selected),
+		formatOperations(m.operations),
+	)
+}
@@ -6,12 +6,14 @@ package matchers_test
 import (
 	"fmt"
 
+	"github.com/ironcore-dev/controller-utils/clientutils"
 	. "github.com/ironcore-dev/controller-utils/testutils/matchers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/semantic"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("Matchers", func() {
@@ -124,4 +126,54 @@ var _ = Describe("Matchers", func() {
 			})
 		})
 	})
+
+	Context("OperationsMatcher", func() {
+		ops := []clientutils.Operation{
+			{
+				Verb: clientutils.VerbCreate,
+				GVK:  schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+				Key:  client.ObjectKey{Namespace: "default", Name: "foo"},
+			},
+			{
+				Verb: clientutils.VerbDelete,
+				GVK:  schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+				Key:  client.ObjectKey{Namespace: "default", Name: "foo"},
+			},
+		}
+
+		Describe("Match", func() {
+			It("should match the number of selected operations", func() {
+				matcher := OperationsMatcher{
+					Filters: []clientutils.OperationFilter{clientutils.OperationVerbs(clientutils.VerbDelete)},
+					Count:   Equal(1),
+				}
+
+				Expect(matcher.Match(ops)).To(BeTrue())
+				Expect(matcher.Match(ops[:1])).To(BeFalse())
+				_, err := matcher.Match("foo")
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should error if the count is not set", func() {
+				matcher := OperationsMatcher{}
+				_, err := matcher.Match(ops)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Describe("FailureMessage", func() {
+			It("should report the selected and all operations", func() {
+				matcher := OperationsMatcher{
+					Filters: []clientutils.OperationFilter{clientutils.OperationVerbs(clientutils.VerbDelete)},
+					Count:   BeZero(),
+				}
+
+				Expect(matcher.Match(ops)).To(BeFalse())
+				message := matcher.FailureMessage(ops)
+				Expect(message).To(HavePrefix("unexpected number of selected operations:"))
+				Expect(message).To(HaveSuffix("selected operations:\n    delete ConfigMap/default/foo\n" +
+					"all operations:\n    create ConfigMap/default/foo\n    delete ConfigMap/default/foo"))
+			})
+		})
+	})
 })
@@ -4,7 +4,10 @@
 package testutils
 
 import (
+	"github.com/ironcore-dev/controller-utils/clientutils"
 	"github.com/ironcore-dev/controller-utils/testutils/matchers"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/utils/semantic"
 )
@@ -55,3 +58,24 @@ func MatchNamedErrorFunc(name string, f func(err error) bool) matchers.ErrorFunc
 		Func: f,
 	}
 }
+
+// HaveOperations returns a matcher that determines whether the number of operations recorded by a
+// clientutils.RecordingClient that are selected by all given filters matches the count matcher.
+func HaveOperations(count types.GomegaMatcher, filters ...clientutils.OperationFilter) *matchers.OperationsMatcher {
+	return &matchers.OperationsMatcher{
+		Filters: filters,
+		Count:   count,
+	}
+}
+
+// HaveOperationOnce returns a matcher that determines whether exactly one operation recorded by a
+// clientutils.RecordingClient is selected by all given filters.
+func HaveOperationOnce(filters ...clientutils.OperationFilter) *matchers.OperationsMatcher {
+	return HaveOperations(gomega.Equal(1), filters...)
+}
+
+// HaveNoOperations returns a matcher that determines whether no operation recorded by a
+// clientutils.RecordingClient is selected by all given filters.
+func HaveNoOperations(filters ...clientutils.OperationFilter) *matchers.OperationsMatcher {
+	return HaveOperations(gomega.BeZero(), filters...)
+}